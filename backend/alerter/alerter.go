@@ -0,0 +1,93 @@
+package alerter
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/hyoa/wall-eve/backend/internal/alert"
+	"github.com/hyoa/wall-eve/backend/internal/indexation"
+	"github.com/hyoa/wall-eve/backend/internal/namespace"
+	log "github.com/sirupsen/logrus"
+)
+
+type Alerter struct {
+	client *goredis.Client
+}
+
+func Create(client *goredis.Client) Alerter {
+	return Alerter{
+		client: client,
+	}
+}
+
+// Run evaluate every rule each time a region has been indexed.
+func (a *Alerter) Run() {
+	lastIdChecked, _ := a.client.Get(context.Background(), namespace.Key("alerter:indexationFinishedLastId")).Result()
+
+	// A first start only evaluate the next indexations, the old ones would fire outdated alerts
+	if lastIdChecked == "" {
+		lastIdChecked = "0"
+		if last, _ := a.client.XRevRangeN(context.Background(), namespace.Key("indexationFinished"), "+", "-", 1).Result(); len(last) > 0 {
+			lastIdChecked = last[0].ID
+		}
+	}
+
+	log.Infoln("Listen stream for finished indexation to evaluate alerts")
+	for {
+		xReadArgs := goredis.XReadArgs{
+			Streams: []string{namespace.Key("indexationFinished"), lastIdChecked},
+			Count:   1,
+			Block:   2 * time.Second,
+		}
+		res, _ := a.client.XRead(context.Background(), &xReadArgs).Result()
+
+		if len(res) > 0 && len(res[0].Messages) > 0 {
+			message := res[0].Messages[0]
+
+			var regionId int
+			if val, ok := message.Values["regionId"].(string); ok {
+				regionId, _ = strconv.Atoi(val)
+			}
+
+			// A failed indexation did not change the region
+			if status, _ := message.Values["status"].(string); regionId != 0 && status != indexation.StatusFailed {
+				a.evaluateRules(regionId)
+			}
+
+			lastIdChecked = message.ID
+			a.client.Set(context.Background(), namespace.Key("alerter:indexationFinishedLastId"), lastIdChecked, 0)
+		}
+	}
+}
+
+func (a *Alerter) evaluateRules(regionId int) {
+	rules, errRules := alert.GetAllRules(a.client)
+
+	if errRules != nil {
+		log.Errorln(errRules)
+		return
+	}
+
+	for _, rule := range rules {
+		matches, errEvaluate := alert.Evaluate(rule, regionId, a.client)
+
+		if errEvaluate != nil {
+			log.Errorf("Unable to evaluate rule %s: %s", rule.Id, errEvaluate.Error())
+			continue
+		}
+
+		if len(matches) == 0 {
+			continue
+		}
+
+		fired, errFire := alert.Fire(rule, regionId, matches, a.client)
+
+		if errFire != nil {
+			log.Errorln(errFire)
+		} else if fired {
+			log.Infof("Rule %s fired for %d items in region %d", rule.Id, len(matches), regionId)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package alertercmd
+
+import "github.com/spf13/cobra"
+
+var (
+	rootCmd = &cobra.Command{}
+)
+
+func Execute() error {
+	return rootCmd.Execute()
+}
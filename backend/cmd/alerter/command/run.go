@@ -0,0 +1,25 @@
+package alertercmd
+
+import (
+	"os"
+
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/hyoa/wall-eve/backend/alerter"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+}
+
+var checkCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Read indexation stream to evaluate the alert rules",
+	Run: func(cmd *cobra.Command, args []string) {
+		var addr = os.Getenv("REDIS_ADDR")
+		client := goredis.NewClient(&goredis.Options{Addr: addr, Username: os.Getenv("REDIS_USER"), Password: os.Getenv("REDIS_PASSWORD")})
+
+		a := alerter.Create(client)
+		a.Run()
+	},
+}
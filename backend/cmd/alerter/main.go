@@ -0,0 +1,7 @@
+package main
+
+import _cmd "github.com/hyoa/wall-eve/backend/cmd/alerter/command"
+
+func main() {
+	_cmd.Execute()
+}
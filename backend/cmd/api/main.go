@@ -1,23 +1,113 @@
 package main
 
 import (
+	"net"
 	"os"
+	"strings"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	goredis "github.com/go-redis/redis/v8"
 	"github.com/hyoa/wall-eve/backend/controller"
+	"github.com/hyoa/wall-eve/backend/grpcserver"
+	"github.com/hyoa/wall-eve/backend/internal/graph"
+	"github.com/hyoa/wall-eve/backend/internal/marketpb"
+	"github.com/hyoa/wall-eve/backend/internal/marketwatch"
+	"github.com/hyoa/wall-eve/backend/internal/querycache"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
 )
 
 func main() {
 	var addr = os.Getenv("REDIS_ADDR")
 	client := goredis.NewClient(&goredis.Options{Addr: addr, Username: os.Getenv("REDIS_USER"), Password: os.Getenv("REDIS_PASSWORD")})
 
-	c := controller.NewOrderController(client)
+	cache := querycache.NewCache(client)
+	go cache.WatchIndexations()
+
+	// One subscription to the updates of the indexer for every stream of the api
+	hub := marketwatch.NewHub(client)
+	go hub.Run()
+
+	c := controller.NewOrderController(cache, hub, client)
+	uc := controller.NewUniverseController(client)
+	ac := controller.NewAlertController(client)
+
+	schema, errSchema := graph.NewSchema(client)
+	if errSchema != nil {
+		log.Fatalln(errSchema)
+	}
+	gc := controller.NewGraphqlController(schema)
+
+	go serveGrpc(hub, client)
 
 	r := gin.Default()
-	r.Use(cors.Default())
+	if errProxies := r.SetTrustedProxies(trustedProxies()); errProxies != nil {
+		log.Fatalln(errProxies)
+	}
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowAllOrigins = true
+	corsConfig.AddAllowHeaders(controller.HeaderApiKey, "Authorization")
+	corsConfig.AddExposeHeaders(controller.RateLimitHeaders...)
+	r.Use(cors.New(corsConfig))
+	r.Use(controller.RateLimit(client))
 	r.GET("/market", c.GetDenormOrdersWithFilter)
+	r.GET("/market/stream", c.StreamMarket)
+	r.GET("/market/:locationId/:typeId", c.GetDenormOrder)
+	r.GET("/regions", uc.GetRegions)
+	r.GET("/regions/:regionId/systems", uc.GetSystemsInRegion)
+	r.GET("/systems/:systemId/locations", uc.GetLocationsInSystem)
+	rules := r.Group("/alerts/rules", controller.RequireApiKey())
+	rules.POST("", ac.CreateRule)
+	rules.GET("", ac.GetRules)
+	rules.GET("/:ruleId", ac.GetRule)
+	rules.PUT("/:ruleId", ac.UpdateRule)
+	rules.DELETE("/:ruleId", ac.DeleteRule)
+	rules.GET("/:ruleId/history", ac.GetHistory)
+	r.GET("/graphql", gc.Query)
+	r.POST("/graphql", gc.Query)
 
 	r.Run(":1337")
 }
+
+// trustedProxies read TRUSTED_PROXIES, a comma separated list of ips or cidrs. Without it no proxy is trusted
+// and X-Forwarded-For is ignored, the rate limit use the ip of the connection.
+func trustedProxies() []string {
+	value := os.Getenv("TRUSTED_PROXIES")
+	if value == "" {
+		return nil
+	}
+
+	var proxies []string
+	for _, proxy := range strings.Split(value, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+
+	return proxies
+}
+
+// serveGrpc expose the market service next to the http api, on GRPC_ADDR (default :1338).
+func serveGrpc(hub *marketwatch.Hub, client *goredis.Client) {
+	addr := os.Getenv("GRPC_ADDR")
+	if addr == "" {
+		addr = ":1338"
+	}
+
+	lis, errListen := net.Listen("tcp", addr)
+	if errListen != nil {
+		log.Fatalln(errListen)
+	}
+
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(grpcserver.UnaryRateLimit(client)),
+		grpc.StreamInterceptor(grpcserver.StreamRateLimit(client)),
+	)
+	marketpb.RegisterMarketServer(server, grpcserver.NewMarketServer(hub, client))
+
+	log.Infof("gRPC server listening on %s", addr)
+	if errServe := server.Serve(lis); errServe != nil {
+		log.Fatalln(errServe)
+	}
+}
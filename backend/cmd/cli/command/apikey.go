@@ -0,0 +1,191 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/hyoa/wall-eve/backend/internal/apikey"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var rateLimit int
+var dailyQuota int
+var usageDays int
+
+func init() {
+	apikeyCmd.PersistentFlags().StringVarP((&envFile), "envFile", "e", "", "env file location")
+	apikeyCreateCmd.Flags().IntVar(&rateLimit, "rateLimit", 0, fmt.Sprintf("requests by minute (default %d)", apikey.DefaultTier.RateLimit))
+	apikeyCreateCmd.Flags().IntVar(&dailyQuota, "dailyQuota", 0, fmt.Sprintf("requests by day (default %d)", apikey.DefaultTier.DailyQuota))
+	apikeyUsageCmd.Flags().IntVar(&usageDays, "days", 7, "number of days of usage to show, at most 31")
+
+	apikeyCmd.AddCommand(apikeyCreateCmd, apikeyListCmd, apikeyRevokeCmd, apikeyUsageCmd)
+	rootCmd.AddCommand(apikeyCmd)
+}
+
+var apikeyCmd = &cobra.Command{
+	Use:   "apikey",
+	Short: "Manage the api keys",
+}
+
+var apikeyCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Issue a key, its token is only shown once",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client, errClient := newApikeyClient()
+
+		if errClient != nil {
+			return
+		}
+
+		if rateLimit < 0 || dailyQuota < 0 {
+			log.Errorln("rateLimit and dailyQuota must be positive")
+			return
+		}
+
+		key, token, errIssue := apikey.Issue(args[0], rateLimit, dailyQuota, client)
+
+		if errIssue != nil {
+			log.Errorln(errIssue)
+			return
+		}
+
+		fmt.Printf("id: %s\ntoken: %s\nrateLimit: %d/min\ndailyQuota: %d/day\n", key.Id, token, key.RateLimit, key.DailyQuota)
+	},
+}
+
+var apikeyListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the keys",
+	Run: func(cmd *cobra.Command, args []string) {
+		client, errClient := newApikeyClient()
+
+		if errClient != nil {
+			return
+		}
+
+		keys, errKeys := apikey.GetAll(client)
+
+		if errKeys != nil {
+			log.Errorln(errKeys)
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tRATE LIMIT\tDAILY QUOTA\tCREATED AT\tREVOKED AT")
+		for _, key := range keys {
+			revokedAt := "-"
+			if key.RevokedAt != nil {
+				revokedAt = key.RevokedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\t%s\n", key.Id, key.Name, key.RateLimit, key.DailyQuota, key.CreatedAt.Format(time.RFC3339), revokedAt)
+		}
+		w.Flush()
+	},
+}
+
+var apikeyRevokeCmd = &cobra.Command{
+	Use:   "revoke",
+	Short: "Revoke a key, its usage is kept",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client, errClient := newApikeyClient()
+
+		if errClient != nil {
+			return
+		}
+
+		key, errRevoke := apikey.Revoke(args[0], client)
+
+		if errors.Is(errRevoke, apikey.ErrNotFound) {
+			log.Errorf("Unknown key %s", args[0])
+			return
+		}
+
+		if errRevoke != nil {
+			log.Errorln(errRevoke)
+			return
+		}
+
+		log.Infof("Key %s (%s) revoked", key.Id, key.Name)
+	},
+}
+
+var apikeyUsageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Show the requests of a key",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client, errClient := newApikeyClient()
+
+		if errClient != nil {
+			return
+		}
+
+		if usageDays < 1 || usageDays > 31 {
+			log.Errorln("days must be between 1 and 31")
+			return
+		}
+
+		key, errKey := apikey.Get(args[0], client)
+
+		if errors.Is(errKey, apikey.ErrNotFound) {
+			log.Errorf("Unknown key %s", args[0])
+			return
+		}
+
+		if errKey != nil {
+			log.Errorln(errKey)
+			return
+		}
+
+		usage, errUsage := apikey.GetUsage(key.Id, usageDays, client)
+
+		if errUsage != nil {
+			log.Errorln(errUsage)
+			return
+		}
+
+		lastUsedAt := "never"
+		if !usage.LastUsedAt.IsZero() {
+			lastUsedAt = usage.LastUsedAt.Format(time.RFC3339)
+		}
+
+		fmt.Printf("%s (%s)\ntotal: %d\nlastUsedAt: %s\n\n", key.Id, key.Name, usage.Total, lastUsedAt)
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "DAY\tREQUESTS")
+		for _, day := range usage.ByDay {
+			fmt.Fprintf(w, "%s\t%d/%d\n", day.Day, day.Requests, key.DailyQuota)
+		}
+		fmt.Fprintln(w, "\nROUTE\tREQUESTS")
+
+		routes := make([]string, 0, len(usage.ByRoute))
+		for route := range usage.ByRoute {
+			routes = append(routes, route)
+		}
+		sort.Strings(routes)
+
+		for _, route := range routes {
+			fmt.Fprintf(w, "%s\t%d\n", route, usage.ByRoute[route])
+		}
+		w.Flush()
+	},
+}
+
+func newApikeyClient() (*goredis.Client, error) {
+	if envFile != "" {
+		if err := loadEnv(); err != nil {
+			return nil, err
+		}
+	}
+
+	var addr = os.Getenv("REDIS_ADDR")
+	return goredis.NewClient(&goredis.Options{Addr: addr, Username: os.Getenv("REDIS_USER"), Password: os.Getenv("REDIS_PASSWORD")}), nil
+}
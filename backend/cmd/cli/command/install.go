@@ -5,12 +5,17 @@ import (
 	"os"
 
 	goredis "github.com/go-redis/redis/v8"
+	"github.com/hyoa/wall-eve/backend/internal/denormorder"
+	"github.com/hyoa/wall-eve/backend/internal/namespace"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
+var recreate bool
+
 func init() {
 	installCmd.Flags().StringVarP((&envFile), "envFile", "e", "", "env file location")
+	installCmd.Flags().BoolVar(&recreate, "recreate", false, "drop the index before creating it, to apply a new schema")
 	rootCmd.AddCommand(installCmd)
 }
 
@@ -29,25 +34,16 @@ var installCmd = &cobra.Command{
 		var addr = os.Getenv("REDIS_ADDR")
 		client := goredis.NewClient(&goredis.Options{Addr: addr, Username: os.Getenv("REDIS_USER"), Password: os.Getenv("REDIS_PASSWORD")})
 
-		_, errCreateIdx := client.Do(
-			context.Background(),
-			"FT.CREATE", "denormalizedOrdersIdx",
-			"ON", "JSON",
-			"PREFIX", "1", "denormalizedOrders:",
-			"SCHEMA",
-			"$.regionId", "AS", "regionId", "NUMERIC",
-			"$.systemId", "AS", "systemId", "NUMERIC",
-			"$.locationId", "AS", "locationId", "NUMERIC",
-			"$.typeId", "AS", "typeId", "NUMERIC",
-			"$.buyPrice", "AS", "buyPrice", "NUMERIC",
-			"$.sellPrice", "AS", "sellPrice", "NUMERIC",
-			"$.locationName", "AS", "locationName", "TEXT",
-			"$.regionName", "AS", "regionName", "TEXT",
-			"$.systemName", "AS", "systemName", "TEXT",
-			"$.typeName", "AS", "typeName", "TEXT",
-			"$.locationNameConcat", "AS", "locationNameConcat", "TEXT",
-			"$.locationIdTags", "AS", "locationIdTags", "TAG", "SEPARATOR", ",",
-		).Result()
+		// The documents are kept, only the index is dropped and rebuilt from them
+		if recreate {
+			if errDrop := client.Do(context.Background(), "FT.DROPINDEX", namespace.Key("denormalizedOrdersIdx")).Err(); errDrop != nil {
+				log.Errorln(errDrop.Error())
+			} else {
+				log.Infoln("Index denormalizedOrdersIdx dropped")
+			}
+		}
+
+		_, errCreateIdx := client.Do(context.Background(), denormorder.IndexCreateArgs()...).Result()
 
 		if errCreateIdx != nil {
 			log.Errorln(errCreateIdx.Error())
@@ -55,7 +51,7 @@ var installCmd = &cobra.Command{
 			log.Infoln("Index denormalizedOrdersIdx created")
 		}
 
-		_, errCreateXGroup := client.XGroupCreateMkStream(context.Background(), "indexationAdd", "indexationAddGroup", "0").Result()
+		_, errCreateXGroup := client.XGroupCreateMkStream(context.Background(), namespace.Key("indexationAdd"), "indexationAddGroup", "0").Result()
 
 		if errCreateXGroup != nil {
 			log.Errorln(errCreateXGroup.Error())
@@ -0,0 +1,20 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/hyoa/wall-eve/backend/internal/denormorder"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+}
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the command creating the index, as run by install",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Print(denormorder.IndexCreateCommand())
+	},
+}
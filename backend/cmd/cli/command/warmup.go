@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	goredis "github.com/go-redis/redis/v8"
+	"github.com/hyoa/wall-eve/backend/internal/namespace"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -34,11 +35,11 @@ var warmupCmd = &cobra.Command{
 		regionId, _ := strconv.Atoi(args[0])
 
 		xAddArgs := goredis.XAddArgs{
-			Stream: "indexationAdd",
+			Stream: namespace.Key("indexationAdd"),
 			Values: []interface{}{"regionId", regionId},
 		}
 		client.XAdd(context.Background(), &xAddArgs)
 
-		log.Infoln("Ask indexation for ", regionId)
+		log.Infof("Ask indexation for %d", regionId)
 	},
 }
@@ -5,12 +5,19 @@ import (
 
 	goredis "github.com/go-redis/redis/v8"
 	"github.com/hyoa/wall-eve/backend/delayer"
+	"github.com/hyoa/wall-eve/backend/internal/esi"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
 type chanIndex struct{}
 
+var esiMode string
+var esiCassetteDir string
+
 func init() {
+	checkCmd.Flags().StringVar(&esiMode, "esiMode", "", "record or replay ESI responses (default to ESI_MODE)")
+	checkCmd.Flags().StringVar(&esiCassetteDir, "esiCassetteDir", "", "directory of the recorded ESI responses (default to ESI_CASSETTE_DIR)")
 	rootCmd.AddCommand(checkCmd)
 }
 
@@ -21,7 +28,15 @@ var checkCmd = &cobra.Command{
 		var addr = os.Getenv("REDIS_ADDR")
 		client := goredis.NewClient(&goredis.Options{Addr: addr, Username: os.Getenv("REDIS_USER"), Password: os.Getenv("REDIS_PASSWORD")})
 
-		delayer := delayer.Create(client)
+		esiConfig := esi.ConfigWithFlags(esiMode, esiCassetteDir)
+		esiConfig.Redis = client
+		esiClient, errEsi := esi.NewClient(esiConfig)
+
+		if errEsi != nil {
+			log.Fatalln(errEsi)
+		}
+
+		delayer := delayer.Create(client, esiClient)
 		delayer.Run()
 	},
 }
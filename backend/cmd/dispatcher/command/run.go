@@ -0,0 +1,46 @@
+package dispatchercmd
+
+import (
+	"os"
+
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/hyoa/wall-eve/backend/dispatcher"
+	"github.com/hyoa/wall-eve/backend/internal/notifier"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var configPath string
+
+func init() {
+	checkCmd.Flags().StringVar(&configPath, "config", os.Getenv("NOTIFIER_CONFIG"), "json file of the channels and the events sent to them")
+	rootCmd.AddCommand(checkCmd)
+}
+
+var checkCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Read events streams to send them to Discord, Slack or webhooks",
+	Run: func(cmd *cobra.Command, args []string) {
+		var addr = os.Getenv("REDIS_ADDR")
+		client := goredis.NewClient(&goredis.Options{Addr: addr, Username: os.Getenv("REDIS_USER"), Password: os.Getenv("REDIS_PASSWORD")})
+
+		if configPath == "" {
+			log.Fatalln("a notifier config is required, set NOTIFIER_CONFIG or --config")
+		}
+
+		config, errConfig := notifier.LoadConfig(configPath)
+
+		if errConfig != nil {
+			log.Fatalln(errConfig)
+		}
+
+		n, errDispatcher := notifier.NewDispatcher(config, client)
+
+		if errDispatcher != nil {
+			log.Fatalln(errDispatcher)
+		}
+
+		d := dispatcher.Create(n, client)
+		d.Run()
+	},
+}
@@ -0,0 +1,49 @@
+package fakeesicmd
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/hyoa/wall-eve/backend/internal/fakeesi"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var addr string
+var config fakeesi.Config
+
+func init() {
+	checkCmd.Flags().StringVar(&addr, "addr", ":8081", "address to listen on")
+	checkCmd.Flags().Int64Var(&config.Seed, "seed", 1, "seed of the generated data and faults")
+	checkCmd.Flags().IntSliceVar(&config.Regions, "regions", fakeesi.DefaultRegions, "regions to serve")
+	checkCmd.Flags().IntVar(&config.SystemsPerRegion, "systems", 5, "systems per region")
+	checkCmd.Flags().IntVar(&config.StationsPerSystem, "stations", 3, "stations per system")
+	checkCmd.Flags().IntVar(&config.PagesPerRegion, "pages", 3, "pages of orders per region, at most 999")
+	checkCmd.Flags().IntVar(&config.OrdersPerPage, "ordersPerPage", 1000, "orders per page, below 100000")
+	checkCmd.Flags().DurationVar(&config.Latency, "latency", 0, "latency added to every response")
+	checkCmd.Flags().Float64Var(&config.ErrorRate, "errorRate", 0, "probability to answer a 5xx")
+	checkCmd.Flags().Float64Var(&config.ErrorLimitRate, "errorLimitRate", 0, "probability to answer a 420")
+	checkCmd.Flags().Float64Var(&config.TruncateRate, "truncateRate", 0, "probability to truncate a body")
+	rootCmd.AddCommand(checkCmd)
+}
+
+var checkCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Serve a fake ESI for development and tests",
+	Run: func(cmd *cobra.Command, args []string) {
+		handler, errConfig := fakeesi.New(config)
+
+		if errConfig != nil {
+			log.Fatalln(errConfig)
+		}
+
+		server := &http.Server{
+			Addr:              addr,
+			Handler:           handler,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+
+		log.Infof("Fake ESI listen on %s, use ESI_BASE_URL=http://127.0.0.1%s/latest", addr, addr)
+		log.Fatalln(server.ListenAndServe())
+	},
+}
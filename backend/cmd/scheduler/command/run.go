@@ -4,6 +4,7 @@ import (
 	"os"
 
 	goredis "github.com/go-redis/redis/v8"
+	"github.com/hyoa/wall-eve/backend/internal/esi"
 	"github.com/hyoa/wall-eve/backend/scheduler"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -11,7 +12,12 @@ import (
 
 type chanIndex struct{}
 
+var esiMode string
+var esiCassetteDir string
+
 func init() {
+	checkCmd.Flags().StringVar(&esiMode, "esiMode", "", "record or replay ESI responses (default to ESI_MODE)")
+	checkCmd.Flags().StringVar(&esiCassetteDir, "esiCassetteDir", "", "directory of the recorded ESI responses (default to ESI_CASSETTE_DIR)")
 	rootCmd.AddCommand(checkCmd)
 }
 
@@ -22,7 +28,14 @@ var checkCmd = &cobra.Command{
 
 		var addr = os.Getenv("REDIS_ADDR")
 		client := goredis.NewClient(&goredis.Options{Addr: addr, Username: os.Getenv("REDIS_USER"), Password: os.Getenv("REDIS_PASSWORD")})
-		scheduler := scheduler.Create(client)
+		esiConfig := esi.ConfigWithFlags(esiMode, esiCassetteDir)
+		esiConfig.Redis = client
+		esiClient, errEsi := esi.NewClient(esiConfig)
+
+		if errEsi != nil {
+			log.Fatalln(errEsi)
+		}
+		scheduler := scheduler.Create(client, esiClient)
 
 		deferedFunc := func() {
 			if err := client.Close(); err != nil {
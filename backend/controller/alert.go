@@ -0,0 +1,169 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/hyoa/wall-eve/backend/internal/alert"
+)
+
+const (
+	defaultHistoryLimit = 20
+	maxHistoryLimit     = 100
+)
+
+type AlertController struct {
+	client *goredis.Client
+}
+
+func NewAlertController(client *goredis.Client) AlertController {
+	return AlertController{
+		client: client,
+	}
+}
+
+func (ac *AlertController) CreateRule(ctx *gin.Context) {
+	rule, ok := bindRule(ctx)
+
+	if !ok {
+		return
+	}
+
+	rule.Id = ""
+	rule.Owner = ctx.GetString(contextApiKeyId)
+	saved, errSave := alert.SaveRule(rule, ac.client)
+
+	if errSave != nil {
+		respondWithInternalError(ctx, "unable to save the rule")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, saved)
+}
+
+func (ac *AlertController) GetRules(ctx *gin.Context) {
+	rules, err := alert.GetRules(ctx.GetString(contextApiKeyId), ac.client)
+
+	if err != nil {
+		respondWithInternalError(ctx, "unable to read the rules")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rules)
+}
+
+func (ac *AlertController) GetRule(ctx *gin.Context) {
+	rule, ok := ac.getRule(ctx)
+
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rule)
+}
+
+func (ac *AlertController) UpdateRule(ctx *gin.Context) {
+	existing, ok := ac.getRule(ctx)
+
+	if !ok {
+		return
+	}
+
+	rule, ok := bindRule(ctx)
+
+	if !ok {
+		return
+	}
+
+	rule.Id = existing.Id
+	rule.Owner = existing.Owner
+	rule.CreatedAt = existing.CreatedAt
+	saved, errSave := alert.SaveRule(rule, ac.client)
+
+	if errSave != nil {
+		respondWithInternalError(ctx, "unable to save the rule")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, saved)
+}
+
+func (ac *AlertController) DeleteRule(ctx *gin.Context) {
+	err := alert.DeleteRule(ctx.GetString(contextApiKeyId), ctx.Param("ruleId"), ac.client)
+
+	if errors.Is(err, alert.ErrNotFound) {
+		respondWithError(ctx, http.StatusNotFound, newApiError(CodeNotFound, "", err.Error()))
+		return
+	}
+
+	if err != nil {
+		respondWithInternalError(ctx, "unable to delete the rule")
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (ac *AlertController) GetHistory(ctx *gin.Context) {
+	rule, ok := ac.getRule(ctx)
+
+	if !ok {
+		return
+	}
+
+	q := newQueryParams(ctx)
+	limit := q.Int("limit", defaultHistoryLimit, 1, maxHistoryLimit)
+
+	if errParams := q.Err(); errParams != nil {
+		respondWithError(ctx, http.StatusBadRequest, errParams)
+		return
+	}
+
+	history, err := alert.GetHistory(rule.Id, int64(limit), ac.client)
+
+	if err != nil {
+		respondWithInternalError(ctx, "unable to read the history")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, history)
+}
+
+// getRule write the error response itself when the rule can not be read, the rules of other api keys are not found.
+func (ac *AlertController) getRule(ctx *gin.Context) (alert.Rule, bool) {
+	rule, err := alert.GetRule(ctx.GetString(contextApiKeyId), ctx.Param("ruleId"), ac.client)
+
+	if errors.Is(err, alert.ErrNotFound) {
+		respondWithError(ctx, http.StatusNotFound, newApiError(CodeNotFound, "", err.Error()))
+		return alert.Rule{}, false
+	}
+
+	if err != nil {
+		respondWithInternalError(ctx, "unable to read the rule")
+		return alert.Rule{}, false
+	}
+
+	return rule, true
+}
+
+func bindRule(ctx *gin.Context) (alert.Rule, bool) {
+	var rule alert.Rule
+
+	if errBind := ctx.ShouldBindJSON(&rule); errBind != nil {
+		respondWithError(ctx, http.StatusBadRequest, newApiError(CodeInvalidBody, "", "body must be a json rule"))
+		return alert.Rule{}, false
+	}
+
+	var errField *alert.FieldError
+	if errValidate := rule.Validate(); errors.As(errValidate, &errField) {
+		respondWithError(ctx, http.StatusBadRequest, newApiError(CodeInvalidBody, errField.Field, errField.Message))
+		return alert.Rule{}, false
+	} else if errValidate != nil {
+		respondWithError(ctx, http.StatusBadRequest, newApiError(CodeInvalidBody, "", errValidate.Error()))
+		return alert.Rule{}, false
+	}
+
+	return rule, true
+}
@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// cacheMaxAge match the cache of the ESI market orders, the data can not change faster.
+const cacheMaxAge = 5 * time.Minute
+
+// respondWithCache write v as json with the caching headers, or a 304 when the client already has it.
+func respondWithCache(ctx *gin.Context, v interface{}, lastModified time.Time) {
+	body, errMarshal := json.Marshal(v)
+
+	if errMarshal != nil {
+		respondWithInternalError(ctx, "unable to encode the response")
+		return
+	}
+
+	etag := fmt.Sprintf("\"%x\"", sha1.Sum(body))
+
+	ctx.Header("ETag", etag)
+	if isNotModified(ctx.Request, etag, lastModified) {
+		respondNotModified(ctx, lastModified)
+		return
+	}
+
+	cacheHeaders(ctx, lastModified)
+
+	ctx.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// isNotModified follow RFC 7232: If-Modified-Since is ignored when If-None-Match is sent.
+func isNotModified(req *http.Request, etag string, lastModified time.Time) bool {
+	if inm := req.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == etag {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if ims := req.Header.Get("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		since, errParse := http.ParseTime(ims)
+
+		// The header has a second precision
+		return errParse == nil && !lastModified.Truncate(time.Second).After(since)
+	}
+
+	return false
+}
+
+// respondNotModified answer a 304 with the caching headers of the response the client already has.
+func respondNotModified(ctx *gin.Context, lastModified time.Time) {
+	cacheHeaders(ctx, lastModified)
+	ctx.Status(http.StatusNotModified)
+}
+
+// cacheHeaders keep the responses to an api key out of the shared caches, they are counted in its rate limit.
+func cacheHeaders(ctx *gin.Context, lastModified time.Time) {
+	visibility := "public"
+	if ctx.GetString(contextApiKeyId) != "" {
+		visibility = "private"
+	}
+
+	ctx.Header("Cache-Control", fmt.Sprintf("%s, max-age=%.f", visibility, cacheMaxAge.Seconds()))
+	ctx.Header("Vary", "Authorization, "+HeaderApiKey)
+	if !lastModified.IsZero() {
+		ctx.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+}
@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestIsNotModified(t *testing.T) {
+	lastModified := time.Date(2026, 10, 19, 12, 0, 0, 500, time.UTC)
+	etag := `"abc"`
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		etag    string
+		want    bool
+	}{
+		{"no condition", nil, etag, false},
+		{"same etag", map[string]string{"If-None-Match": `"xyz", W/"abc"`}, etag, true},
+		{"other etag", map[string]string{"If-None-Match": `"xyz"`}, etag, false},
+		{"etag take precedence", map[string]string{"If-None-Match": `"xyz"`, "If-Modified-Since": lastModified.Format(http.TimeFormat)}, etag, false},
+		{"not modified since", map[string]string{"If-Modified-Since": lastModified.Format(http.TimeFormat)}, etag, true},
+		{"modified since", map[string]string{"If-Modified-Since": lastModified.Add(-time.Minute).Format(http.TimeFormat)}, etag, false},
+		{"date only, before the search", map[string]string{"If-Modified-Since": lastModified.Format(http.TimeFormat)}, "", true},
+		{"invalid date", map[string]string{"If-Modified-Since": "yesterday"}, etag, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/market?location=jita", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			if got := isNotModified(req, tt.etag, lastModified); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCacheHeaders(t *testing.T) {
+	tests := []struct {
+		name     string
+		apiKeyId string
+		want     string
+	}{
+		{"anonymous", "", "public, max-age=300"},
+		{"api key", "key-1", "private, max-age=300"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+			if tt.apiKeyId != "" {
+				ctx.Set(contextApiKeyId, tt.apiKeyId)
+			}
+
+			cacheHeaders(ctx, time.Time{})
+
+			if got := w.Header().Get("Cache-Control"); got != tt.want {
+				t.Errorf("got Cache-Control %q, want %q", got, tt.want)
+			}
+
+			if got := w.Header().Get("Vary"); got != "Authorization, "+HeaderApiKey {
+				t.Errorf("got Vary %q", got)
+			}
+
+			if got := w.Header().Get("Last-Modified"); got != "" {
+				t.Errorf("got Last-Modified %q without a date", got)
+			}
+		})
+	}
+}
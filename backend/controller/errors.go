@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	CodeMissingParameter = "missing_parameter"
+	CodeInvalidParameter = "invalid_parameter"
+	CodeUnknownParameter = "unknown_parameter"
+	CodeInvalidBody      = "invalid_body"
+	CodeNotFound         = "not_found"
+	CodeUnauthorized     = "unauthorized"
+	CodeRateLimited      = "rate_limited"
+	CodeQuotaExceeded    = "quota_exceeded"
+	CodeInternal         = "internal_error"
+)
+
+// ApiError is the body of every error of the api, Field is the parameter or the body field at fault.
+type ApiError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Field   string `json:"field,omitempty"`
+}
+
+func (e *ApiError) Error() string {
+	return e.Message
+}
+
+func newApiError(code string, field string, format string, args ...interface{}) *ApiError {
+	return &ApiError{Code: code, Field: field, Message: fmt.Sprintf(format, args...)}
+}
+
+// respondWithError write the error and stop the handlers chain.
+func respondWithError(ctx *gin.Context, status int, err *ApiError) {
+	ctx.AbortWithStatusJSON(status, err)
+}
+
+func respondWithInternalError(ctx *gin.Context, message string) {
+	respondWithError(ctx, http.StatusInternalServerError, newApiError(CodeInternal, "", message))
+}
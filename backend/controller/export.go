@@ -0,0 +1,188 @@
+package controller
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyoa/wall-eve/backend/internal/denormorder"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	formatJson   = "json"
+	formatCsv    = "csv"
+	formatNdjson = "ndjson"
+	// An export is read from redis by batches of a cursor and flushed to the client after each of them
+	exportBatchSize = 1000
+	maxExportLimit  = 100000
+)
+
+// csvColumns is the order of the columns of the CSV export, new columns must be added at the end.
+var csvColumns = []struct {
+	name  string
+	value func(o denormorder.DenormalizedOrder) string
+}{
+	{"regionId", func(o denormorder.DenormalizedOrder) string { return strconv.Itoa(o.RegionId) }},
+	{"systemId", func(o denormorder.DenormalizedOrder) string { return strconv.Itoa(o.SystemId) }},
+	{"locationId", func(o denormorder.DenormalizedOrder) string { return strconv.Itoa(o.LocationId) }},
+	{"typeId", func(o denormorder.DenormalizedOrder) string { return strconv.Itoa(o.TypeId) }},
+	{"regionName", func(o denormorder.DenormalizedOrder) string { return o.RegionName }},
+	{"systemName", func(o denormorder.DenormalizedOrder) string { return o.SystemName }},
+	{"locationName", func(o denormorder.DenormalizedOrder) string { return o.LocationName }},
+	{"typeName", func(o denormorder.DenormalizedOrder) string { return o.TypeName }},
+	{"buyPrice", func(o denormorder.DenormalizedOrder) string { return formatFloat(o.BuyPrice) }},
+	{"sellPrice", func(o denormorder.DenormalizedOrder) string { return formatFloat(o.SellPrice) }},
+	{"buyVolume", func(o denormorder.DenormalizedOrder) string { return strconv.Itoa(o.BuyVolume) }},
+	{"sellVolume", func(o denormorder.DenormalizedOrder) string { return strconv.Itoa(o.SellVolume) }},
+	{"spread", func(o denormorder.DenormalizedOrder) string { return formatFloat(o.Spread) }},
+	{"margin", func(o denormorder.DenormalizedOrder) string { return formatFloat(o.Margin) }},
+	{"indexedAt", func(o denormorder.DenormalizedOrder) string { return formatTime(o.IndexedAt) }},
+	{"esiLastModified", func(o denormorder.DenormalizedOrder) string { return formatTime(o.EsiLastModified) }},
+}
+
+// negotiateFormat read the format query parameter first, then the Accept header. JSON is the default.
+func negotiateFormat(ctx *gin.Context, q *queryParams) string {
+	if val := q.Enum("format", "", formatJson, formatCsv, formatNdjson); val != "" {
+		return val
+	}
+
+	for _, accept := range strings.Split(ctx.GetHeader("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err != nil {
+			continue
+		}
+
+		switch mediaType {
+		case "text/csv":
+			return formatCsv
+		case "application/x-ndjson", "application/ndjson":
+			return formatNdjson
+		case "application/json", "*/*":
+			return formatJson
+		}
+	}
+
+	return formatJson
+}
+
+// streamExport write the orders as they are read from redis. The first batch is read before the status,
+// so a search that fail is a 500, an error after it close the connection to not end the body cleanly.
+func (mc *MarketController) streamExport(ctx *gin.Context, format string, filter denormorder.Filter, onFirst func(o denormorder.DenormalizedOrder)) {
+	cursor, errOpen := denormorder.OpenOrderCursor(filter, exportBatchSize, mc.client)
+
+	if errOpen != nil {
+		log.Errorf("Unable to start the export: %s", errOpen.Error())
+		respondWithInternalError(ctx, "unable to read the market")
+		return
+	}
+	defer cursor.Close()
+
+	orders, errNext := cursor.Next()
+
+	if errNext != nil {
+		log.Errorf("Unable to start the export: %s", errNext.Error())
+		respondWithInternalError(ctx, "unable to read the market")
+		return
+	}
+
+	w := bufio.NewWriter(ctx.Writer)
+	var write func(o denormorder.DenormalizedOrder) error
+
+	switch format {
+	case formatCsv:
+		ctx.Header("Content-Type", "text/csv; charset=utf-8")
+		ctx.Header("Content-Disposition", `attachment; filename="market.csv"`)
+
+		cw := csv.NewWriter(w)
+		header := make([]string, 0, len(csvColumns))
+		for _, column := range csvColumns {
+			header = append(header, column.name)
+		}
+		// The header is there even without any order
+		cw.Write(header)
+		cw.Flush()
+
+		record := make([]string, len(csvColumns))
+		write = func(o denormorder.DenormalizedOrder) error {
+			for k, column := range csvColumns {
+				record[k] = column.value(o)
+			}
+			cw.Write(record)
+			cw.Flush()
+
+			return cw.Error()
+		}
+	default:
+		ctx.Header("Content-Type", "application/x-ndjson; charset=utf-8")
+
+		enc := json.NewEncoder(w)
+		write = func(o denormorder.DenormalizedOrder) error {
+			return enc.Encode(o)
+		}
+	}
+
+	ctx.Status(http.StatusOK)
+
+	if len(orders) > 0 {
+		onFirst(orders[0])
+	}
+
+	count := 0
+	for len(orders) > 0 {
+		for _, o := range orders {
+			if errWrite := write(o); errWrite != nil {
+				abortExport(ctx, count, errWrite)
+				return
+			}
+			count++
+		}
+
+		if errFlush := w.Flush(); errFlush != nil {
+			abortExport(ctx, count, errFlush)
+			return
+		}
+		ctx.Writer.Flush()
+
+		if orders, errNext = cursor.Next(); errNext != nil {
+			abortExport(ctx, count, errNext)
+			return
+		}
+	}
+
+	w.Flush()
+	ctx.Writer.Flush()
+}
+
+// abortExport close the connection of an export that failed after its status, the client see a truncated
+// transfer instead of a body that look complete.
+func abortExport(ctx *gin.Context, count int, err error) {
+	log.Errorf("Export interrupted after %d rows: %s", count, err.Error())
+
+	conn, _, errHijack := ctx.Writer.Hijack()
+
+	if errHijack != nil {
+		log.Errorf("Unable to close the connection of the export: %s", errHijack.Error())
+		return
+	}
+
+	conn.Close()
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAbortExport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.GET("/market", func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+		ctx.Writer.WriteString("regionId,systemId\n")
+		ctx.Writer.Flush()
+
+		abortExport(ctx, 0, errors.New("cursor not found"))
+	})
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	res, errGet := http.Get(server.URL + "/market")
+
+	if errGet != nil {
+		t.Fatal(errGet)
+	}
+	defer res.Body.Close()
+
+	body, errRead := io.ReadAll(res.Body)
+
+	if res.StatusCode != http.StatusOK || string(body) != "regionId,systemId\n" {
+		t.Errorf("got status %d and body %q, want the rows written before the error", res.StatusCode, body)
+	}
+
+	if !errors.Is(errRead, io.ErrUnexpectedEOF) {
+		t.Errorf("got %v, want a truncated transfer", errRead)
+	}
+}
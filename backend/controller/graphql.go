@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyoa/wall-eve/backend/internal/graph"
+)
+
+type GraphqlController struct {
+	schema graph.Schema
+}
+
+func NewGraphqlController(schema graph.Schema) GraphqlController {
+	return GraphqlController{
+		schema: schema,
+	}
+}
+
+// Query accept a GET with the query in the url or a POST with a json body, like most GraphQL servers.
+func (gc *GraphqlController) Query(ctx *gin.Context) {
+	var request graph.Request
+
+	if ctx.Request.Method == http.MethodGet {
+		request.Query = ctx.Query("query")
+		request.OperationName = ctx.Query("operationName")
+	} else if errBind := ctx.ShouldBindJSON(&request); errBind != nil {
+		respondWithError(ctx, http.StatusBadRequest, newApiError(CodeInvalidBody, "", "body must be a json with a query"))
+		return
+	}
+
+	if request.Query == "" {
+		respondWithError(ctx, http.StatusBadRequest, newApiError(CodeMissingParameter, "query", "query is mandatory"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gc.schema.Do(ctx.Request.Context(), request))
+}
@@ -3,83 +3,232 @@ package controller
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math"
 	"net/http"
 	"strconv"
-	"strings"
 
 	"github.com/gin-gonic/gin"
 	goredis "github.com/go-redis/redis/v8"
 	"github.com/hyoa/wall-eve/backend/internal/denormorder"
+	"github.com/hyoa/wall-eve/backend/internal/marketwatch"
+	"github.com/hyoa/wall-eve/backend/internal/namespace"
+	"github.com/hyoa/wall-eve/backend/internal/querycache"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 10000
 )
 
 type MarketController struct {
+	cache  *querycache.Cache
+	hub    *marketwatch.Hub
 	client *goredis.Client
 }
 
-func NewOrderController(client *goredis.Client) MarketController {
+func NewOrderController(cache *querycache.Cache, hub *marketwatch.Hub, client *goredis.Client) MarketController {
 	return MarketController{
+		cache:  cache,
+		hub:    hub,
 		client: client,
 	}
 }
 
 func (mc *MarketController) GetDenormOrdersWithFilter(ctx *gin.Context) {
+	q := newQueryParams(ctx)
+	format := negotiateFormat(ctx, q)
+	filter := createFilter(q, format)
 
-	filter, errFilter := createFilter(ctx)
+	if errParams := q.Err(); errParams != nil {
+		respondWithError(ctx, http.StatusBadRequest, errParams)
+		return
+	}
 
-	if errFilter != nil {
-		ctx.JSON(http.StatusBadRequest, map[string]string{"error": errFilter.Error()})
+	if format != formatJson {
+		mc.streamExport(ctx, format, filter, func(o denormorder.DenormalizedOrder) {
+			mc.client.Publish(context.Background(), namespace.Key("apiEvent"), o.RegionId)
+		})
+		return
 	}
 
-	orders, _ := denormorder.GetDenormalizedOrdersWithFilter(filter, mc.client)
+	result, hit := mc.cache.Lookup(filter)
 
-	if len(orders) > 0 {
-		regionId := orders[0].RegionId
-		mc.client.Publish(context.Background(), "apiEvent", regionId)
+	// Without the search only the date of the copy of the client can be checked, it is enough for If-Modified-Since
+	if !hit && ctx.GetHeader("If-None-Match") == "" && ctx.GetHeader("If-Modified-Since") != "" {
+		if lastModified := mc.cache.LastModified(filter.Location); isNotModified(ctx.Request, "", lastModified) {
+			respondNotModified(ctx, lastModified)
+			return
+		}
 	}
 
-	ctx.JSON(http.StatusOK, orders)
-}
+	if !hit {
+		var errSearch error
+		result, hit, errSearch = mc.cache.Search(filter)
 
-func createFilter(ctx *gin.Context) (denormorder.Filter, error) {
-	var filter denormorder.Filter
+		if errSearch != nil {
+			respondWithInternalError(ctx, "unable to read the market")
+			return
+		}
+	}
 
-	if val := ctx.Query("location"); val != "" {
-		filter.Location = strings.ReplaceAll(val, "-", "")
+	if hit {
+		ctx.Header("X-Cache", "HIT")
 	} else {
-		return denormorder.Filter{}, errors.New("query parameter location is mandatory")
+		ctx.Header("X-Cache", "MISS")
 	}
 
-	if val := ctx.Query("typeName"); val != "" {
-		filter.TypeName = val
+	if len(result.Orders) > 0 {
+		regionId := result.Orders[0].RegionId
+		mc.client.Publish(context.Background(), namespace.Key("apiEvent"), regionId)
 	}
 
-	if val := ctx.Query("minBuyPrice"); val != "" {
-		v, _ := strconv.ParseFloat(val, 64)
-		filter.MinBuyPrice = v
-	} else {
-		filter.MinBuyPrice = 0
+	if !q.Bool("envelope", true) {
+		respondWithCache(ctx, result.Orders, result.LastModified)
+		return
 	}
 
-	if val := ctx.Query("maxBuyPrice"); val != "" {
-		v, _ := strconv.ParseFloat(val, 64)
-		filter.MaxBuyPrice = v
-	} else {
-		filter.MaxBuyPrice = 1000000000000
+	respondWithCache(ctx, createMarketPage(ctx, filter, result), result.LastModified)
+}
+
+type MarketItem struct {
+	Data      denormorder.DenormalizedOrder `json:"data"`
+	Freshness denormorder.Freshness         `json:"freshness"`
+}
+
+func (mc *MarketController) GetDenormOrder(ctx *gin.Context) {
+	locationId, errLocation := strconv.Atoi(ctx.Param("locationId"))
+
+	if errLocation != nil || locationId < 1 {
+		respondWithError(ctx, http.StatusBadRequest, newApiError(CodeInvalidParameter, "locationId", "locationId must be a positive integer"))
+		return
 	}
 
-	if val := ctx.Query("minSellPrice"); val != "" {
-		v, _ := strconv.ParseFloat(val, 64)
-		filter.MinSellPrice = v
-	} else {
-		filter.MinSellPrice = 0
+	typeId, errType := strconv.Atoi(ctx.Param("typeId"))
+
+	if errType != nil || typeId < 1 {
+		respondWithError(ctx, http.StatusBadRequest, newApiError(CodeInvalidParameter, "typeId", "typeId must be a positive integer"))
+		return
 	}
 
-	if val := ctx.Query("maxSellPrice"); val != "" {
-		v, _ := strconv.ParseFloat(val, 64)
-		filter.MaxSellPrice = v
-	} else {
-		filter.MaxSellPrice = 1000000000000
+	if errParams := newQueryParams(ctx).Err(); errParams != nil {
+		respondWithError(ctx, http.StatusBadRequest, errParams)
+		return
+	}
+
+	order, freshness, errGet := denormorder.GetDenormalizedOrder(locationId, typeId, mc.client)
+
+	if errors.Is(errGet, denormorder.ErrNotFound) {
+		respondWithError(ctx, http.StatusNotFound, newApiError(CodeNotFound, "", errGet.Error()))
+		return
+	}
+
+	if errGet != nil {
+		respondWithInternalError(ctx, "unable to read the market")
+		return
+	}
+
+	mc.client.Publish(context.Background(), namespace.Key("apiEvent"), order.RegionId)
+
+	respondWithCache(ctx, MarketItem{Data: order, Freshness: freshness}, order.IndexedAt)
+}
+
+type MarketPage struct {
+	Data  []denormorder.DenormalizedOrder `json:"data"`
+	Total int                             `json:"total"`
+	Page  PageInfo                        `json:"page"`
+	Links PageLinks                       `json:"links"`
+}
+
+type PageInfo struct {
+	Limit   int  `json:"limit"`
+	Offset  int  `json:"offset"`
+	Count   int  `json:"count"`
+	HasNext bool `json:"hasNext"`
+}
+
+type PageLinks struct {
+	Self string `json:"self"`
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
+
+func createMarketPage(ctx *gin.Context, filter denormorder.Filter, result denormorder.SearchResult) MarketPage {
+	hasNext := filter.Offset+len(result.Orders) < result.Total
+
+	links := PageLinks{Self: pageLink(ctx, filter.Limit, filter.Offset)}
+	if hasNext {
+		links.Next = pageLink(ctx, filter.Limit, filter.Offset+filter.Limit)
+	}
+	if filter.Offset > 0 {
+		prevOffset := filter.Offset - filter.Limit
+		if prevOffset < 0 {
+			prevOffset = 0
+		}
+		links.Prev = pageLink(ctx, filter.Limit, prevOffset)
+	}
+
+	return MarketPage{
+		Data:  result.Orders,
+		Total: result.Total,
+		Page: PageInfo{
+			Limit:   filter.Limit,
+			Offset:  filter.Offset,
+			Count:   len(result.Orders),
+			HasNext: hasNext,
+		},
+		Links: links,
+	}
+}
+
+// pageLink keep the query of the current request and only change the page.
+func pageLink(ctx *gin.Context, limit int, offset int) string {
+	query := ctx.Request.URL.Query()
+	query.Set("limit", strconv.Itoa(limit))
+	query.Set("offset", strconv.Itoa(offset))
+
+	return fmt.Sprintf("%s?%s", ctx.Request.URL.Path, query.Encode())
+}
+
+func createFilter(q *queryParams, format string) denormorder.Filter {
+	filter := denormorder.NewFilter(q.Required("location"))
+	filter.TypeName = q.String("typeName")
+	filter.TypeNameMatch = q.Enum("typeNameMatch", denormorder.TypeNameMatchText, denormorder.TypeNameMatchText, denormorder.TypeNameMatchPrefix, denormorder.TypeNameMatchFuzzy)
+	filter.TypeIds = q.IntList("typeId", denormorder.MaxTypeIds, 1)
+
+	limit := defaultLimit
+	// The array response was not paginated, keep it returning every entry
+	envelope := q.Bool("envelope", true)
+	if !envelope {
+		limit = maxLimit
+	}
+
+	// An export is streamed, it can return a whole hub
+	limitMax := maxLimit
+	if format != formatJson {
+		limit = maxExportLimit
+		limitMax = maxExportLimit
+	}
+
+	filter.Limit = q.Int("limit", limit, 1, limitMax)
+	filter.Offset = q.Int("offset", 0, 0, math.MaxInt32)
+	filter.SortBy = q.Enum("sortBy", "", denormorder.SortableFields...)
+	filter.SortOrder = q.Enum("order", "", "asc", "desc")
+
+	filter.MinBuyPrice = q.Float("minBuyPrice", 0, 0, denormorder.MaxPrice)
+	filter.MaxBuyPrice = q.Float("maxBuyPrice", denormorder.MaxPrice, 0, denormorder.MaxPrice)
+	filter.MinSellPrice = q.Float("minSellPrice", 0, 0, denormorder.MaxPrice)
+	filter.MaxSellPrice = q.Float("maxSellPrice", denormorder.MaxPrice, 0, denormorder.MaxPrice)
+	filter.MinBuyVolume = q.Int64("minBuyVolume", 0, 0, denormorder.MaxVolume)
+	filter.MaxBuyVolume = q.Int64("maxBuyVolume", denormorder.MaxVolume, 0, denormorder.MaxVolume)
+	filter.MinSellVolume = q.Int64("minSellVolume", 0, 0, denormorder.MaxVolume)
+	filter.MaxSellVolume = q.Int64("maxSellVolume", denormorder.MaxVolume, 0, denormorder.MaxVolume)
+
+	// The values are read, the checks between them are the ones of every api
+	var errFilter *denormorder.FilterError
+	if errors.As(filter.Validate(), &errFilter) {
+		q.fail(CodeInvalidParameter, errFilter.Field, "query parameter %s", errFilter.Message)
 	}
 
-	return filter, nil
+	return filter
 }
@@ -0,0 +1,192 @@
+package controller
+
+import (
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// queryParams read the query parameters of a request with their type and their bounds. The first invalid
+// parameter is kept as the error and the next reads return their default value.
+type queryParams struct {
+	values url.Values
+	known  map[string]bool
+	err    *ApiError
+}
+
+func newQueryParams(ctx *gin.Context) *queryParams {
+	return &queryParams{
+		values: ctx.Request.URL.Query(),
+		known:  make(map[string]bool),
+	}
+}
+
+// Err return the first invalid parameter, or the first parameter that has not been read.
+func (q *queryParams) Err() *ApiError {
+	if q.err != nil {
+		return q.err
+	}
+
+	for name := range q.values {
+		if !q.known[name] {
+			return newApiError(CodeUnknownParameter, name, "query parameter %s is unknown", name)
+		}
+	}
+
+	return nil
+}
+
+func (q *queryParams) fail(code string, name string, format string, args ...interface{}) {
+	if q.err == nil {
+		q.err = newApiError(code, name, format, args...)
+	}
+}
+
+// get return the value of the parameter, a parameter given many times is refused.
+func (q *queryParams) get(name string) (string, bool) {
+	q.known[name] = true
+	values, ok := q.values[name]
+
+	if !ok || q.err != nil {
+		return "", false
+	}
+
+	if len(values) > 1 {
+		q.fail(CodeInvalidParameter, name, "query parameter %s must be given once", name)
+		return "", false
+	}
+
+	if values[0] == "" {
+		return "", false
+	}
+
+	return values[0], true
+}
+
+func (q *queryParams) String(name string) string {
+	val, _ := q.get(name)
+
+	return val
+}
+
+func (q *queryParams) Required(name string) string {
+	val, ok := q.get(name)
+
+	if !ok {
+		q.fail(CodeMissingParameter, name, "query parameter %s is mandatory", name)
+	}
+
+	return val
+}
+
+func (q *queryParams) Enum(name string, defaultValue string, values ...string) string {
+	val, ok := q.get(name)
+
+	if !ok {
+		return defaultValue
+	}
+
+	for _, v := range values {
+		if val == v {
+			return val
+		}
+	}
+
+	q.fail(CodeInvalidParameter, name, "query parameter %s must be one of %s", name, strings.Join(values, ", "))
+
+	return defaultValue
+}
+
+func (q *queryParams) Bool(name string, defaultValue bool) bool {
+	val, ok := q.get(name)
+
+	if !ok {
+		return defaultValue
+	}
+
+	v, err := strconv.ParseBool(val)
+	if err != nil {
+		q.fail(CodeInvalidParameter, name, "query parameter %s must be true or false", name)
+		return defaultValue
+	}
+
+	return v
+}
+
+func (q *queryParams) Int(name string, defaultValue int, min int, max int) int {
+	val, ok := q.get(name)
+
+	if !ok {
+		return defaultValue
+	}
+
+	v, err := strconv.Atoi(val)
+	if err != nil || v < min || v > max {
+		q.fail(CodeInvalidParameter, name, "query parameter %s must be an integer between %d and %d", name, min, max)
+		return defaultValue
+	}
+
+	return v
+}
+
+func (q *queryParams) Int64(name string, defaultValue int64, min int64, max int64) int64 {
+	val, ok := q.get(name)
+
+	if !ok {
+		return defaultValue
+	}
+
+	v, err := strconv.ParseInt(val, 10, 64)
+	if err != nil || v < min || v > max {
+		q.fail(CodeInvalidParameter, name, "query parameter %s must be an integer between %d and %d", name, min, max)
+		return defaultValue
+	}
+
+	return v
+}
+
+func (q *queryParams) Float(name string, defaultValue float64, min float64, max float64) float64 {
+	val, ok := q.get(name)
+
+	if !ok {
+		return defaultValue
+	}
+
+	v, err := strconv.ParseFloat(val, 64)
+	if err != nil || math.IsNaN(v) || v < min || v > max {
+		q.fail(CodeInvalidParameter, name, "query parameter %s must be a number between %s and %s", name, formatFloat(min), formatFloat(max))
+		return defaultValue
+	}
+
+	return v
+}
+
+// IntList read a comma separated list of integers.
+func (q *queryParams) IntList(name string, maxItems int, min int) []int {
+	val, ok := q.get(name)
+
+	if !ok {
+		return nil
+	}
+
+	items := strings.Split(val, ",")
+	if len(items) > maxItems {
+		q.fail(CodeInvalidParameter, name, "query parameter %s accept at most %d values", name, maxItems)
+		return nil
+	}
+
+	values := make([]int, 0, len(items))
+	for _, item := range items {
+		v, err := strconv.Atoi(strings.TrimSpace(item))
+		if err != nil || v < min {
+			q.fail(CodeInvalidParameter, name, "query parameter %s must be a comma separated list of integers of at least %d", name, min)
+			return nil
+		}
+		values = append(values, v)
+	}
+
+	return values
+}
@@ -0,0 +1,144 @@
+package controller
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyoa/wall-eve/backend/internal/denormorder"
+)
+
+func newTestQueryParams(rawQuery string) *queryParams {
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest("GET", "/market?"+rawQuery, nil)
+
+	return newQueryParams(ctx)
+}
+
+func TestCreateFilter(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		wantCode  string
+		wantField string
+	}{
+		{"valid", "location=jita&typeId=34,35&minBuyPrice=1.5&maxBuyPrice=10&sortBy=margin&order=desc&limit=50", "", ""},
+		{"missing location", "typeId=34", CodeMissingParameter, "location"},
+		{"empty location", "location=", CodeMissingParameter, "location"},
+		{"location without term", "location=---", CodeInvalidParameter, "location"},
+		{"unknown parameter", "location=jita&foo=1", CodeUnknownParameter, "foo"},
+		{"duplicate parameter", "location=jita&location=amarr", CodeInvalidParameter, "location"},
+		{"duplicate limit", "location=jita&limit=1&limit=2", CodeInvalidParameter, "limit"},
+		{"non numeric price", "location=jita&minBuyPrice=abc", CodeInvalidParameter, "minBuyPrice"},
+		{"nan price", "location=jita&maxSellPrice=NaN", CodeInvalidParameter, "maxSellPrice"},
+		{"negative buy price", "location=jita&minBuyPrice=-1", CodeInvalidParameter, "minBuyPrice"},
+		{"negative sell price", "location=jita&maxSellPrice=-0.5", CodeInvalidParameter, "maxSellPrice"},
+		{"price above max", "location=jita&maxBuyPrice=1e13", CodeInvalidParameter, "maxBuyPrice"},
+		{"non numeric volume", "location=jita&minSellVolume=ten", CodeInvalidParameter, "minSellVolume"},
+		{"negative volume", "location=jita&minBuyVolume=-1", CodeInvalidParameter, "minBuyVolume"},
+		{"buy price min above max", "location=jita&minBuyPrice=10&maxBuyPrice=5", CodeInvalidParameter, "minBuyPrice"},
+		{"sell price min above max", "location=jita&minSellPrice=10&maxSellPrice=5", CodeInvalidParameter, "minSellPrice"},
+		{"buy volume min above max", "location=jita&minBuyVolume=10&maxBuyVolume=5", CodeInvalidParameter, "minBuyVolume"},
+		{"sell volume min above max", "location=jita&minSellVolume=10&maxSellVolume=5", CodeInvalidParameter, "minSellVolume"},
+		{"min equal to max", "location=jita&minSellPrice=5&maxSellPrice=5", "", ""},
+		{"invalid type ids", "location=jita&typeId=34,a", CodeInvalidParameter, "typeId"},
+		{"zero type id", "location=jita&typeId=0", CodeInvalidParameter, "typeId"},
+		{"limit too low", "location=jita&limit=0", CodeInvalidParameter, "limit"},
+		{"limit too high", "location=jita&limit=10001", CodeInvalidParameter, "limit"},
+		{"negative offset", "location=jita&offset=-1", CodeInvalidParameter, "offset"},
+		{"unknown sort field", "location=jita&sortBy=nope", CodeInvalidParameter, "sortBy"},
+		{"unknown order", "location=jita&order=up", CodeInvalidParameter, "order"},
+		{"invalid envelope", "location=jita&envelope=maybe", CodeInvalidParameter, "envelope"},
+		{"invalid type name match", "location=jita&typeName=trit&typeNameMatch=regex", CodeInvalidParameter, "typeNameMatch"},
+		{"short prefix", "location=jita&typeName=t&typeNameMatch=prefix", CodeInvalidParameter, "typeName"},
+		{"first error wins", "minBuyPrice=abc&foo=1", CodeMissingParameter, "location"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newTestQueryParams(tt.query)
+			createFilter(q, formatJson)
+			err := q.Err()
+
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatalf("unexpected error %+v", err)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatalf("expected %s on %s, got no error", tt.wantCode, tt.wantField)
+			}
+
+			if err.Code != tt.wantCode || err.Field != tt.wantField {
+				t.Errorf("got %s on %s, want %s on %s", err.Code, err.Field, tt.wantCode, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestCreateFilterDefaults(t *testing.T) {
+	q := newTestQueryParams("location=jita")
+	filter := createFilter(q, formatJson)
+
+	if err := q.Err(); err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+
+	if filter.Limit != defaultLimit || filter.Offset != 0 {
+		t.Errorf("got limit %d offset %d, want %d and 0", filter.Limit, filter.Offset, defaultLimit)
+	}
+
+	if filter.MaxBuyPrice != denormorder.MaxPrice || filter.MaxSellPrice != denormorder.MaxPrice || filter.MaxBuyVolume != denormorder.MaxVolume || filter.MaxSellVolume != denormorder.MaxVolume {
+		t.Errorf("got maximums %+v, want the defaults", filter)
+	}
+
+	q = newTestQueryParams("location=jita&envelope=false")
+	if filter := createFilter(q, formatJson); filter.Limit != maxLimit {
+		t.Errorf("got limit %d without envelope, want %d", filter.Limit, maxLimit)
+	}
+
+	q = newTestQueryParams("location=jita")
+	if filter := createFilter(q, formatCsv); filter.Limit != maxExportLimit {
+		t.Errorf("got limit %d for an export, want %d", filter.Limit, maxExportLimit)
+	}
+}
+
+func TestQueryParams(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		read      func(q *queryParams)
+		wantCode  string
+		wantField string
+	}{
+		{"int in range", "n=5", func(q *queryParams) { q.Int("n", 0, 1, 10) }, "", ""},
+		{"int below range", "n=0", func(q *queryParams) { q.Int("n", 0, 1, 10) }, CodeInvalidParameter, "n"},
+		{"int not a number", "n=1.5", func(q *queryParams) { q.Int("n", 0, 1, 10) }, CodeInvalidParameter, "n"},
+		{"float infinite", "f=inf", func(q *queryParams) { q.Float("f", 0, 0, 10) }, CodeInvalidParameter, "f"},
+		{"required missing", "", func(q *queryParams) { q.Required("r") }, CodeMissingParameter, "r"},
+		{"enum unknown", "e=c", func(q *queryParams) { q.Enum("e", "a", "a", "b") }, CodeInvalidParameter, "e"},
+		{"list too long", "l=1,2,3", func(q *queryParams) { q.IntList("l", 2, 1) }, CodeInvalidParameter, "l"},
+		{"unread parameter", "a=1&b=2", func(q *queryParams) { q.String("a") }, CodeUnknownParameter, "b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newTestQueryParams(tt.query)
+			tt.read(q)
+			err := q.Err()
+
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatalf("unexpected error %+v", err)
+				}
+				return
+			}
+
+			if err == nil || err.Code != tt.wantCode || err.Field != tt.wantField {
+				t.Errorf("got %+v, want %s on %s", err, tt.wantCode, tt.wantField)
+			}
+		})
+	}
+}
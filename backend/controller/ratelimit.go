@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/hyoa/wall-eve/backend/internal/apikey"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	HeaderApiKey = "X-Api-Key"
+	// contextApiKeyId is the id of the api key of the request in the gin context, it is not set for anonymous callers.
+	contextApiKeyId = "apiKeyId"
+)
+
+// RateLimitHeaders are exposed to the browsers by cors.
+var RateLimitHeaders = []string{"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-Quota-Limit", "X-Quota-Remaining", "Retry-After"}
+
+// RateLimit apply the limits of the api key of the request, or of the anonymous tier by ip without a key.
+func RateLimit(client *goredis.Client) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token := ctx.GetHeader(HeaderApiKey)
+		if token == "" {
+			token = strings.TrimPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+		}
+
+		caller, errIdentify := apikey.Identify(token, ctx.ClientIP(), client)
+
+		if errors.Is(errIdentify, apikey.ErrNotFound) || errors.Is(errIdentify, apikey.ErrRevoked) {
+			respondWithError(ctx, http.StatusUnauthorized, newApiError(CodeUnauthorized, HeaderApiKey, errIdentify.Error()))
+			return
+		}
+
+		if errIdentify != nil {
+			log.Errorln(errIdentify)
+			respondWithInternalError(ctx, "unable to check the api key")
+			return
+		}
+
+		decision, errConsume := apikey.Consume(caller.Subject, caller.Tier, client)
+
+		if errConsume != nil {
+			log.Errorln(errConsume)
+			respondWithInternalError(ctx, "unable to check the rate limit")
+			return
+		}
+
+		ctx.Header("X-RateLimit-Limit", strconv.Itoa(decision.RateLimit))
+		ctx.Header("X-RateLimit-Remaining", strconv.Itoa(decision.RateRemaining))
+		ctx.Header("X-Quota-Limit", strconv.Itoa(decision.DailyQuota))
+		ctx.Header("X-Quota-Remaining", strconv.Itoa(decision.QuotaRemaining))
+
+		if !decision.Allowed {
+			ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(decision.Retry.Seconds()))))
+
+			if decision.QuotaExceeded {
+				respondWithError(ctx, http.StatusTooManyRequests, newApiError(CodeQuotaExceeded, "", "daily quota exceeded"))
+				return
+			}
+
+			respondWithError(ctx, http.StatusTooManyRequests, newApiError(CodeRateLimited, "", "rate limit exceeded"))
+			return
+		}
+
+		if caller.Key.Id != "" {
+			ctx.Set(contextApiKeyId, caller.Key.Id)
+
+			route := ctx.FullPath()
+			if route == "" {
+				route = "unknown"
+			}
+
+			if errUsage := apikey.CountUsage(caller.Key.Id, route, client); errUsage != nil {
+				log.Errorln(errUsage)
+			}
+		}
+
+		ctx.Next()
+	}
+}
+
+// RequireApiKey reject the anonymous callers, it must be used after RateLimit.
+func RequireApiKey() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.GetString(contextApiKeyId) == "" {
+			respondWithError(ctx, http.StatusUnauthorized, newApiError(CodeUnauthorized, HeaderApiKey, "an api key is required"))
+			return
+		}
+
+		ctx.Next()
+	}
+}
@@ -0,0 +1,150 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/hyoa/wall-eve/backend/internal/denormorder"
+	"github.com/hyoa/wall-eve/backend/internal/marketwatch"
+	"github.com/hyoa/wall-eve/backend/internal/namespace"
+	log "github.com/sirupsen/logrus"
+)
+
+// streamKeepAlive keep the idle connections open through the proxies, it also mark the watched regions as used.
+const streamKeepAlive = 30 * time.Second
+
+var upgrader = websocket.Upgrader{
+	// Same policy than the cors middleware, every origin is allowed
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+type StreamMessage struct {
+	Event string                         `json:"event"`
+	Data  *denormorder.DenormalizedOrder `json:"data,omitempty"`
+}
+
+// StreamMarket send the items matching the filters of /market each time an indexation change them,
+// with Server-Sent Events or with a WebSocket when the client ask for an upgrade.
+func (mc *MarketController) StreamMarket(ctx *gin.Context) {
+	q := newQueryParams(ctx)
+	filter := createFilter(q, formatJson)
+
+	if errParams := q.Err(); errParams != nil {
+		respondWithError(ctx, http.StatusBadRequest, errParams)
+		return
+	}
+
+	// Every matching item is watched unless a limit is given, there is no page
+	filter.Offset = 0
+	if ctx.Query("limit") == "" {
+		filter.Limit = marketwatch.MaxFilterItems
+	}
+
+	if websocket.IsWebSocketUpgrade(ctx.Request) {
+		mc.streamWebsocket(ctx, filter)
+		return
+	}
+
+	mc.streamSse(ctx, filter)
+}
+
+func (mc *MarketController) streamSse(ctx *gin.Context, filter denormorder.Filter) {
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+
+	ctx.SSEvent("ready", StreamMessage{Event: "ready"})
+	ctx.Writer.Flush()
+
+	mc.watch(ctx.Request.Context(), filter, func(msg StreamMessage) error {
+		ctx.SSEvent(msg.Event, msg)
+		ctx.Writer.Flush()
+
+		return ctx.Request.Context().Err()
+	})
+}
+
+func (mc *MarketController) streamWebsocket(ctx *gin.Context, filter denormorder.Filter) {
+	conn, errUpgrade := upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+
+	if errUpgrade != nil {
+		return
+	}
+	defer conn.Close()
+
+	watchCtx, cancel := context.WithCancel(ctx.Request.Context())
+	defer cancel()
+
+	// Nothing is expected from the client, reading only detect when it leave
+	go func() {
+		for {
+			if _, _, errRead := conn.ReadMessage(); errRead != nil {
+				cancel()
+				return
+			}
+		}
+	}()
+
+	if errWrite := conn.WriteJSON(StreamMessage{Event: "ready"}); errWrite != nil {
+		return
+	}
+
+	mc.watch(watchCtx, filter, func(msg StreamMessage) error {
+		conn.SetWriteDeadline(time.Now().Add(streamKeepAlive))
+
+		return conn.WriteJSON(msg)
+	})
+}
+
+// watch call write for every change and a keepalive, from a single goroutine, until ctx is done or write fail.
+func (mc *MarketController) watch(ctx context.Context, filter denormorder.Filter, write func(msg StreamMessage) error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	changes := make(chan denormorder.DenormalizedOrder)
+	errWatch := make(chan error, 1)
+
+	go func() {
+		errWatch <- mc.hub.WatchFilter(ctx, filter, func(o denormorder.DenormalizedOrder) error {
+			select {
+			case changes <- o:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+
+	ticker := time.NewTicker(streamKeepAlive)
+	defer ticker.Stop()
+
+	regionIds := make(map[int]bool)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case err := <-errWatch:
+			if err != nil && ctx.Err() == nil {
+				log.Errorf("Market stream stopped: %s", err.Error())
+			}
+			return
+		case o := <-changes:
+			regionIds[o.RegionId] = true
+			if errWrite := write(StreamMessage{Event: "change", Data: &o}); errWrite != nil {
+				return
+			}
+		case <-ticker.C:
+			for regionId := range regionIds {
+				mc.client.Publish(context.Background(), namespace.Key("apiEvent"), regionId)
+			}
+
+			if errWrite := write(StreamMessage{Event: "keepalive"}); errWrite != nil {
+				return
+			}
+		}
+	}
+}
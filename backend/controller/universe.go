@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/hyoa/wall-eve/backend/internal/universe"
+)
+
+type UniverseController struct {
+	client *goredis.Client
+}
+
+func NewUniverseController(client *goredis.Client) UniverseController {
+	return UniverseController{
+		client: client,
+	}
+}
+
+func (uc *UniverseController) GetRegions(ctx *gin.Context) {
+	regions, err := universe.GetRegions(uc.client)
+
+	if err != nil {
+		respondWithInternalError(ctx, "unable to read the regions")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, regions)
+}
+
+func (uc *UniverseController) GetSystemsInRegion(ctx *gin.Context) {
+	regionId, errRegion := strconv.Atoi(ctx.Param("regionId"))
+
+	if errRegion != nil {
+		respondWithError(ctx, http.StatusBadRequest, newApiError(CodeInvalidParameter, "regionId", "regionId must be an integer"))
+		return
+	}
+
+	systems, err := universe.GetSystemsInRegion(regionId, uc.client)
+
+	if errors.Is(err, universe.ErrNotFound) {
+		respondWithError(ctx, http.StatusNotFound, newApiError(CodeNotFound, "", "region not found"))
+		return
+	}
+
+	if err != nil {
+		respondWithInternalError(ctx, "unable to read the systems")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, systems)
+}
+
+func (uc *UniverseController) GetLocationsInSystem(ctx *gin.Context) {
+	systemId, errSystem := strconv.Atoi(ctx.Param("systemId"))
+
+	if errSystem != nil {
+		respondWithError(ctx, http.StatusBadRequest, newApiError(CodeInvalidParameter, "systemId", "systemId must be an integer"))
+		return
+	}
+
+	locations, err := universe.GetLocationsInSystem(systemId, uc.client)
+
+	if errors.Is(err, universe.ErrNotFound) {
+		respondWithError(ctx, http.StatusNotFound, newApiError(CodeNotFound, "", "system not found"))
+		return
+	}
+
+	if err != nil {
+		respondWithInternalError(ctx, "unable to read the locations")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, locations)
+}
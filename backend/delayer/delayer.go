@@ -6,25 +6,48 @@ import (
 	"time"
 
 	goredis "github.com/go-redis/redis/v8"
+	"github.com/hyoa/wall-eve/backend/internal/esi"
+	"github.com/hyoa/wall-eve/backend/internal/namespace"
 	log "github.com/sirupsen/logrus"
 )
 
+// Delay between two regions released after a downtime, to avoid every indexer hitting ESI at once
+const warmupStagger = 20 * time.Second
+
 type Delayer struct {
-	client *goredis.Client
+	client    *goredis.Client
+	esiClient *esi.Client
 }
 
-func Create(client *goredis.Client) Delayer {
+func Create(client *goredis.Client, esiClient *esi.Client) Delayer {
 	return Delayer{
-		client: client,
+		client:    client,
+		esiClient: esiClient,
 	}
 }
 
 func (d *Delayer) Run() {
 	log.Infoln("Read tasks queue to send indexation")
+	paused := false
 	for {
-		res, _ := d.client.ZRangeWithScores(context.Background(), "indexationDelayed", 0, 0).Result()
+		if !d.esiClient.IsAvailable(context.Background()) {
+			if !paused {
+				log.Infoln("ESI is in downtime, hold queued tasks")
+				paused = true
+			}
 
-		if len(res) > 0 && res[0].Score <= float64(time.Now().Unix()) {
+			time.Sleep(10 * time.Second)
+			continue
+		}
+
+		if paused {
+			d.staggerOverdueTasks()
+			paused = false
+		}
+
+		res, _ := d.client.ZRangeWithScores(context.Background(), namespace.Key("indexationDelayed"), 0, 0).Result()
+
+		if len(res) > 0 && res[0].Score <= float64(d.esiClient.Now().Unix()) {
 			var regionId int
 
 			switch val := res[0].Member.(type) {
@@ -35,13 +58,13 @@ func (d *Delayer) Run() {
 			if regionId != 0 {
 				log.Infoln("Found 1 item to index")
 				xAddArgs := goredis.XAddArgs{
-					Stream: "indexationAdd",
+					Stream: d.releaseStream(regionId),
 					Values: []interface{}{"regionId", regionId},
 				}
 				d.client.XAdd(context.Background(), &xAddArgs)
 			}
 
-			d.client.ZRem(context.Background(), "indexationDelayed", res[0].Member)
+			d.client.ZRem(context.Background(), namespace.Key("indexationDelayed"), res[0].Member)
 		}
 
 		time.Sleep(200 * time.Millisecond)
@@ -53,3 +76,34 @@ func (d *Delayer) Run() {
 		}()
 	}
 }
+
+// releaseStream send a region that has not been validated yet to the scheduler, it validate it with ESI before the indexation.
+func (d *Delayer) releaseStream(regionId int) string {
+	isValid, errValid := d.client.SIsMember(context.Background(), namespace.Key("validRegions"), regionId).Result()
+
+	if errValid != nil || !isValid {
+		return namespace.Key("indexationCatchup")
+	}
+
+	return namespace.Key("indexationAdd")
+}
+
+// staggerOverdueTasks spread the tasks that piled up during the downtime instead of releasing them all at once.
+func (d *Delayer) staggerOverdueTasks() {
+	now := d.esiClient.Now()
+	res, _ := d.client.ZRangeByScore(
+		context.Background(),
+		namespace.Key("indexationDelayed"),
+		&goredis.ZRangeBy{
+			Min: "-inf",
+			Max: strconv.FormatInt(now.Unix(), 10),
+		},
+	).Result()
+
+	log.Infof("ESI is back, warm-up %d queued tasks", len(res))
+
+	for k := range res {
+		score := now.Add(time.Duration(k) * warmupStagger).Unix()
+		d.client.ZAdd(context.Background(), namespace.Key("indexationDelayed"), &goredis.Z{Score: float64(score), Member: res[k]})
+	}
+}
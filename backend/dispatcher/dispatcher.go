@@ -0,0 +1,78 @@
+package dispatcher
+
+import (
+	"context"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/hyoa/wall-eve/backend/internal/namespace"
+	"github.com/hyoa/wall-eve/backend/internal/notifier"
+	log "github.com/sirupsen/logrus"
+)
+
+type Dispatcher struct {
+	notifier *notifier.Dispatcher
+	client   *goredis.Client
+}
+
+func Create(n *notifier.Dispatcher, client *goredis.Client) Dispatcher {
+	return Dispatcher{
+		notifier: n,
+		client:   client,
+	}
+}
+
+// Run read the events published by the workers and the fired alerts to send them out.
+func (d *Dispatcher) Run() {
+	eventsLastId := d.lastId("notificationEvents")
+	alertsLastId := d.lastId("alertFired")
+
+	log.Infoln("Listen streams for events to notify")
+	for {
+		xReadArgs := goredis.XReadArgs{
+			Streams: []string{namespace.Key("notificationEvents"), namespace.Key("alertFired"), eventsLastId, alertsLastId},
+			Count:   10,
+			Block:   2 * time.Second,
+		}
+		res, _ := d.client.XRead(context.Background(), &xReadArgs).Result()
+
+		for _, stream := range res {
+			for _, message := range stream.Messages {
+				if stream.Stream == namespace.Key("alertFired") {
+					d.dispatch(notifier.ParseAlertFired(message))
+					alertsLastId = message.ID
+					d.client.Set(context.Background(), namespace.Key("dispatcher:alertFiredLastId"), alertsLastId, 0)
+					continue
+				}
+
+				d.dispatch(notifier.ParseEvent(message))
+				eventsLastId = message.ID
+				d.client.Set(context.Background(), namespace.Key("dispatcher:notificationEventsLastId"), eventsLastId, 0)
+			}
+		}
+	}
+}
+
+func (d *Dispatcher) dispatch(event notifier.Event, err error) {
+	if err != nil {
+		log.Errorln(err)
+		return
+	}
+
+	d.notifier.Dispatch(context.Background(), event)
+}
+
+// lastId return the last message read from a stream, a first start skip the messages already there.
+func (d *Dispatcher) lastId(stream string) string {
+	lastId, _ := d.client.Get(context.Background(), namespace.Key("dispatcher:"+stream+"LastId")).Result()
+
+	if lastId != "" {
+		return lastId
+	}
+
+	if last, _ := d.client.XRevRangeN(context.Background(), namespace.Key(stream), "+", "-", 1).Result(); len(last) > 0 {
+		return last[0].ID
+	}
+
+	return "0"
+}
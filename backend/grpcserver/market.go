@@ -0,0 +1,197 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/hyoa/wall-eve/backend/internal/denormorder"
+	"github.com/hyoa/wall-eve/backend/internal/marketpb"
+	"github.com/hyoa/wall-eve/backend/internal/marketwatch"
+	"github.com/hyoa/wall-eve/backend/internal/namespace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 10000
+)
+
+type MarketServer struct {
+	marketpb.UnimplementedMarketServer
+	hub    *marketwatch.Hub
+	client *goredis.Client
+}
+
+func NewMarketServer(hub *marketwatch.Hub, client *goredis.Client) *MarketServer {
+	return &MarketServer{
+		hub:    hub,
+		client: client,
+	}
+}
+
+func (s *MarketServer) SearchMarket(ctx context.Context, req *marketpb.SearchMarketRequest) (*marketpb.SearchMarketResponse, error) {
+	filter, errFilter := createFilter(req)
+
+	if errFilter != nil {
+		return nil, status.Error(codes.InvalidArgument, errFilter.Error())
+	}
+
+	result, errSearch := denormorder.GetDenormalizedOrdersWithFilter(filter, s.client)
+
+	if errSearch != nil {
+		return nil, status.Error(codes.Internal, "unable to read the market")
+	}
+
+	if len(result.Orders) > 0 {
+		s.client.Publish(context.Background(), namespace.Key("apiEvent"), result.Orders[0].RegionId)
+	}
+
+	entries := make([]*marketpb.MarketEntry, 0, len(result.Orders))
+	for _, order := range result.Orders {
+		entries = append(entries, toMarketEntry(order))
+	}
+
+	return &marketpb.SearchMarketResponse{
+		Total:   int32(result.Total),
+		HasNext: filter.Offset+len(result.Orders) < result.Total,
+		Entries: entries,
+	}, nil
+}
+
+func (s *MarketServer) GetItem(ctx context.Context, req *marketpb.GetItemRequest) (*marketpb.GetItemResponse, error) {
+	order, freshness, errGet := denormorder.GetDenormalizedOrder(int(req.LocationId), int(req.TypeId), s.client)
+
+	if errors.Is(errGet, denormorder.ErrNotFound) {
+		return nil, status.Error(codes.NotFound, errGet.Error())
+	}
+
+	if errGet != nil {
+		return nil, status.Error(codes.Internal, "unable to read the market")
+	}
+
+	s.client.Publish(context.Background(), namespace.Key("apiEvent"), order.RegionId)
+
+	return &marketpb.GetItemResponse{
+		Entry:     toMarketEntry(order),
+		ExpiresAt: timestamp(freshness.ExpiresAt),
+	}, nil
+}
+
+func (s *MarketServer) WatchItems(req *marketpb.WatchItemsRequest, stream marketpb.Market_WatchItemsServer) error {
+	if len(req.Items) == 0 || len(req.Items) > marketwatch.MaxItems {
+		return status.Errorf(codes.InvalidArgument, "between 1 and %d items can be watched", marketwatch.MaxItems)
+	}
+
+	items := make([]marketwatch.ItemKey, 0, len(req.Items))
+	for _, item := range req.Items {
+		items = append(items, marketwatch.ItemKey{LocationId: int(item.LocationId), TypeId: int(item.TypeId)})
+	}
+
+	errWatch := s.hub.Watch(stream.Context(), items, func(o denormorder.DenormalizedOrder) error {
+		return stream.Send(toMarketEntry(o))
+	})
+
+	// The client went away, it is the normal end of a watch
+	if stream.Context().Err() != nil {
+		return nil
+	}
+
+	return errWatch
+}
+
+// createFilter read the request, the values are checked by the validation of every api.
+func createFilter(req *marketpb.SearchMarketRequest) (denormorder.Filter, error) {
+	filter := denormorder.NewFilter(req.Location)
+	filter.TypeName = req.TypeName
+	filter.Limit = defaultLimit
+	filter.Offset = int(req.Offset)
+	filter.SortBy = req.SortBy
+
+	if req.Limit != 0 {
+		if req.Limit > maxLimit {
+			return denormorder.Filter{}, fmt.Errorf("limit must be between 1 and %d", maxLimit)
+		}
+		filter.Limit = int(req.Limit)
+	}
+
+	if req.Order == marketpb.SortOrder_SORT_ORDER_DESC {
+		filter.SortOrder = "desc"
+	}
+
+	switch req.TypeNameMatch {
+	case marketpb.TypeNameMatch_TYPE_NAME_MATCH_PREFIX:
+		filter.TypeNameMatch = denormorder.TypeNameMatchPrefix
+	case marketpb.TypeNameMatch_TYPE_NAME_MATCH_FUZZY:
+		filter.TypeNameMatch = denormorder.TypeNameMatchFuzzy
+	}
+
+	for _, typeId := range req.TypeIds {
+		filter.TypeIds = append(filter.TypeIds, int(typeId))
+	}
+
+	if req.MinBuyPrice != nil {
+		filter.MinBuyPrice = *req.MinBuyPrice
+	}
+	if req.MaxBuyPrice != nil {
+		filter.MaxBuyPrice = *req.MaxBuyPrice
+	}
+	if req.MinSellPrice != nil {
+		filter.MinSellPrice = *req.MinSellPrice
+	}
+	if req.MaxSellPrice != nil {
+		filter.MaxSellPrice = *req.MaxSellPrice
+	}
+	if req.MinBuyVolume != nil {
+		filter.MinBuyVolume = *req.MinBuyVolume
+	}
+	if req.MaxBuyVolume != nil {
+		filter.MaxBuyVolume = *req.MaxBuyVolume
+	}
+	if req.MinSellVolume != nil {
+		filter.MinSellVolume = *req.MinSellVolume
+	}
+	if req.MaxSellVolume != nil {
+		filter.MaxSellVolume = *req.MaxSellVolume
+	}
+
+	if err := filter.Validate(); err != nil {
+		return denormorder.Filter{}, err
+	}
+
+	return filter, nil
+}
+
+func toMarketEntry(o denormorder.DenormalizedOrder) *marketpb.MarketEntry {
+	return &marketpb.MarketEntry{
+		RegionId:        int32(o.RegionId),
+		SystemId:        int32(o.SystemId),
+		LocationId:      int64(o.LocationId),
+		TypeId:          int32(o.TypeId),
+		RegionName:      o.RegionName,
+		SystemName:      o.SystemName,
+		LocationName:    o.LocationName,
+		TypeName:        o.TypeName,
+		BuyPrice:        o.BuyPrice,
+		SellPrice:       o.SellPrice,
+		BuyVolume:       int64(o.BuyVolume),
+		SellVolume:      int64(o.SellVolume),
+		Spread:          o.Spread,
+		Margin:          o.Margin,
+		IndexedAt:       timestamp(o.IndexedAt),
+		EsiLastModified: timestamp(o.EsiLastModified),
+	}
+}
+
+// timestamp leave the field unset for an unknown time.
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+
+	return timestamppb.New(t)
+}
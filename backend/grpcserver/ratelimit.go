@@ -0,0 +1,96 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/hyoa/wall-eve/backend/internal/apikey"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// MetadataApiKey is the metadata of the api key, the same as the http header.
+const MetadataApiKey = "x-api-key"
+
+// UnaryRateLimit apply the limits of the api keys of the http api to each call.
+func UnaryRateLimit(client *goredis.Client) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := consume(ctx, info.FullMethod, client); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamRateLimit count a stream once, when it is opened.
+func StreamRateLimit(client *goredis.Client) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := consume(ss.Context(), info.FullMethod, client); err != nil {
+			return err
+		}
+
+		return handler(srv, ss)
+	}
+}
+
+func consume(ctx context.Context, method string, client *goredis.Client) error {
+	var token string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(MetadataApiKey); len(values) > 0 {
+			token = values[0]
+		} else if values := md.Get("authorization"); len(values) > 0 {
+			token = strings.TrimPrefix(values[0], "Bearer ")
+		}
+	}
+
+	var ip string
+	if p, ok := peer.FromContext(ctx); ok {
+		ip, _, _ = net.SplitHostPort(p.Addr.String())
+	}
+
+	caller, errIdentify := apikey.Identify(token, ip, client)
+
+	if errors.Is(errIdentify, apikey.ErrNotFound) || errors.Is(errIdentify, apikey.ErrRevoked) {
+		return status.Error(codes.Unauthenticated, errIdentify.Error())
+	}
+
+	if errIdentify != nil {
+		log.Errorln(errIdentify)
+		return status.Error(codes.Internal, "unable to check the api key")
+	}
+
+	decision, errConsume := apikey.Consume(caller.Subject, caller.Tier, client)
+
+	if errConsume != nil {
+		log.Errorln(errConsume)
+		return status.Error(codes.Internal, "unable to check the rate limit")
+	}
+
+	if !decision.Allowed {
+		grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(int(math.Ceil(decision.Retry.Seconds())))))
+
+		if decision.QuotaExceeded {
+			return status.Error(codes.ResourceExhausted, "daily quota exceeded")
+		}
+
+		return status.Error(codes.ResourceExhausted, "rate limit exceeded")
+	}
+
+	if caller.Key.Id != "" {
+		if errUsage := apikey.CountUsage(caller.Key.Id, method, client); errUsage != nil {
+			log.Errorln(errUsage)
+		}
+	}
+
+	return nil
+}
@@ -2,11 +2,11 @@ package heartbeat
 
 import (
 	"context"
-	"fmt"
 	"strconv"
 	"time"
 
 	goredis "github.com/go-redis/redis/v8"
+	"github.com/hyoa/wall-eve/backend/internal/namespace"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -21,7 +21,7 @@ func Create(client *goredis.Client) heartbeat {
 }
 
 func (c *heartbeat) Run() {
-	pubsub := c.client.Subscribe(context.Background(), "apiEvent")
+	pubsub := c.client.Subscribe(context.Background(), namespace.Key("apiEvent"))
 
 	defer pubsub.Close()
 
@@ -41,7 +41,7 @@ func (c *heartbeat) Run() {
 func (c *heartbeat) writeCallHistory(regionId int) error {
 	c.client.Do(
 		context.Background(),
-		"TS.ADD", fmt.Sprintf("regionFetchHistory:%d", regionId),
+		"TS.ADD", namespace.Key("regionFetchHistory", regionId),
 		time.Now().UnixMilli(),
 		1,
 	)
@@ -2,24 +2,32 @@ package indexer
 
 import (
 	"context"
-	"sort"
 	"strconv"
 	"time"
 
 	goredis "github.com/go-redis/redis/v8"
 	"github.com/hyoa/wall-eve/backend/internal/denormorder"
+	"github.com/hyoa/wall-eve/backend/internal/esi"
 	"github.com/hyoa/wall-eve/backend/internal/extradata"
+	"github.com/hyoa/wall-eve/backend/internal/indexation"
+	"github.com/hyoa/wall-eve/backend/internal/marketwatch"
+	"github.com/hyoa/wall-eve/backend/internal/namespace"
+	"github.com/hyoa/wall-eve/backend/internal/notifier"
 	"github.com/hyoa/wall-eve/backend/internal/order"
 	log "github.com/sirupsen/logrus"
 )
 
+const regionTimeout = 10 * time.Minute
+
 type Indexer struct {
-	client *goredis.Client
+	client    *goredis.Client
+	esiClient *esi.Client
 }
 
-func Create(client *goredis.Client) Indexer {
+func Create(client *goredis.Client, esiClient *esi.Client) Indexer {
 	return Indexer{
-		client: client,
+		client:    client,
+		esiClient: esiClient,
 	}
 }
 
@@ -37,7 +45,7 @@ func (i *Indexer) Run(consumerName string) {
 		}
 
 		xAddArgs := goredis.XReadGroupArgs{
-			Streams:  []string{"indexationAdd", idToCheck},
+			Streams:  []string{namespace.Key("indexationAdd"), idToCheck},
 			Count:    1,
 			Block:    2 * time.Second,
 			Group:    "indexationAddGroup",
@@ -60,18 +68,28 @@ func (i *Indexer) Run(consumerName string) {
 
 			regionId := parseMessagePayload(messages[0].Values)
 
-			if regionId != 0 {
-				i.indexOrdersInRegion(regionId)
-				i.notifyEndOfIndexation(regionId)
+			if regionId != 0 && !i.esiClient.IsAvailable(context.Background()) {
+				i.holdUntilEndOfDowntime(regionId)
+			} else if regionId != 0 {
+				status := indexation.StatusSucceeded
+				if errIndex := i.indexOrdersInRegion(regionId); errIndex != nil {
+					status = indexation.StatusFailed
+					log.Errorf("Indexation failed for region %d: %s", regionId, errIndex.Error())
+					if errPublish := notifier.Publish(notifier.EventIndexationFailed, map[string]interface{}{"regionId": regionId, "error": errIndex.Error()}, i.client); errPublish != nil {
+						log.Errorln(errPublish)
+					}
+				}
+				// The scheduler plan the next indexation even after a failure, the other consumers check the status
+				i.notifyEndOfIndexation(regionId, status)
 			}
 
-			_, errAck := i.client.XAck(context.Background(), "indexationAdd", "indexationAddGroup", messages[0].ID).Result()
+			_, errAck := i.client.XAck(context.Background(), namespace.Key("indexationAdd"), "indexationAddGroup", messages[0].ID).Result()
 
 			if errAck != nil {
 				log.Errorln(errAck)
 			}
 
-			_, errDel := i.client.XDel(context.Background(), "indexationAdd", messages[0].ID).Result()
+			_, errDel := i.client.XDel(context.Background(), namespace.Key("indexationAdd"), messages[0].ID).Result()
 
 			if errDel != nil {
 				log.Errorln(errDel)
@@ -90,26 +108,21 @@ func (i *Indexer) Run(consumerName string) {
 	}
 }
 
-func (i *Indexer) indexOrdersInRegion(regionId int) (int, int, error) {
+func (i *Indexer) indexOrdersInRegion(regionId int) error {
 	start := time.Now()
-	log.Infoln("Fetch orders")
-	orders := order.GetOrdersFromEsiForRegion(regionId)
 
-	type keyLocationIdTypeId struct {
-		locationId int
-		typeId     int
-	}
+	ctx, cancel := context.WithTimeout(context.Background(), regionTimeout)
+	defer cancel()
 
-	type ordersMappedByLocationAndType struct {
-		sellPrices  []float64
-		buyPrices   []float64
-		sellVolumes []int
-		buyVolumes  []int
-		systemId    int
-		regionId    int
+	log.Infoln("Fetch and aggregate orders")
+	aggregates, errFetch := order.AggregateOrdersFromEsiForRegion(ctx, regionId, i.esiClient)
+
+	// A partial snapshot would remove items from the market, keep the previous one instead
+	if errFetch != nil {
+		return errFetch
 	}
 
-	ordersMapped := make(map[keyLocationIdTypeId]ordersMappedByLocationAndType)
+	items := aggregates.Items()
 	extraData := make(map[string]map[int]string)
 
 	extraData["stations"] = make(map[int]string)
@@ -117,112 +130,71 @@ func (i *Indexer) indexOrdersInRegion(regionId int) (int, int, error) {
 	extraData["regions"] = make(map[int]string)
 	extraData["types"] = make(map[int]string)
 
-	log.Infof("Group %d orders by location and type", len(orders))
-
-	for k := range orders {
-		// We avoid players structure, as it require an authentication to get some data.
-		// Not hard to do, but out of the scope :)
-		if orders[k].LocationId > 2147483647 {
-			continue
-		}
-
-		var order ordersMappedByLocationAndType
-		key := keyLocationIdTypeId{
-			typeId:     orders[k].TypeId,
-			locationId: orders[k].LocationId,
-		}
-
-		if val, ok := ordersMapped[key]; ok {
-			order = ordersMappedByLocationAndType{
-				sellPrices:  val.sellPrices,
-				buyPrices:   val.buyPrices,
-				sellVolumes: val.sellVolumes,
-				buyVolumes:  val.buyVolumes,
-				regionId:    val.regionId,
-				systemId:    val.systemId,
-			}
-		} else {
-			order = ordersMappedByLocationAndType{
-				sellPrices:  make([]float64, 0),
-				buyPrices:   make([]float64, 0),
-				sellVolumes: make([]int, 0),
-				buyVolumes:  make([]int, 0),
-				regionId:    int(regionId),
-				systemId:    int(orders[k].SystemId),
-			}
-		}
-
-		if orders[k].IsBuyOrder {
-			order.buyPrices = append(order.buyPrices, orders[k].Price)
-			order.buyVolumes = append(order.buyVolumes, int(orders[k].VolumeTotal))
-		} else {
-			order.sellPrices = append(order.sellPrices, orders[k].Price)
-			order.sellVolumes = append(order.sellVolumes, int(orders[k].VolumeTotal))
-		}
-
-		extraData["stations"][int(orders[k].LocationId)] = ""
-		extraData["systems"][int(orders[k].SystemId)] = ""
-		extraData["regions"][int(regionId)] = ""
-		extraData["types"][int(orders[k].TypeId)] = ""
-		ordersMapped[key] = order
+	extraData["regions"][regionId] = ""
+	for _, item := range items {
+		extraData["stations"][item.LocationId] = ""
+		extraData["systems"][item.SystemId] = ""
+		extraData["types"][item.TypeId] = ""
 	}
 
 	log.Infoln("Fetch denormalizedOrders extra data")
-	extraDataWithName := extradata.FetchExtraData(extraData, i.client)
-
-	log.Infof("Denormalized orders %d", len(ordersMapped))
-	denormalizedOrders := make([]denormorder.DenormalizedOrder, 0)
-	for k := range ordersMapped {
-		sort.Float64s(ordersMapped[k].buyPrices)
-		sort.Float64s(ordersMapped[k].sellPrices)
-
-		var maxBuyPrice float64
-		if len(ordersMapped[k].buyPrices) > 0 {
-			maxBuyPrice = ordersMapped[k].buyPrices[len(ordersMapped[k].buyPrices)-1]
-		}
-		var minSellPrice float64
-		if len(ordersMapped[k].sellPrices) > 0 {
-			minSellPrice = ordersMapped[k].sellPrices[0]
-		}
-
-		totalBuyVolume := 0
-		for _, n := range ordersMapped[k].buyVolumes {
-			totalBuyVolume += n
-		}
+	extraDataWithName := extradata.FetchExtraData(extraData, i.client, i.esiClient)
 
-		totalSellVolume := 0
-		for _, n := range ordersMapped[k].sellVolumes {
-			totalSellVolume += n
-		}
+	indexedAt := time.Now().UTC().Truncate(time.Second)
+	esiLastModified := aggregates.LastModified()
 
+	log.Infof("Denormalized orders %d", len(items))
+	denormalizedOrders := make([]denormorder.DenormalizedOrder, 0, len(items))
+	for _, item := range items {
+		spread, margin := denormorder.ComputeSpread(item.BuyPrice, item.SellPrice)
 		denormalizedOrders = append(denormalizedOrders, denormorder.DenormalizedOrder{
-			RegionId:     ordersMapped[k].regionId,
-			LocationId:   k.locationId,
-			SystemId:     ordersMapped[k].systemId,
-			TypeId:       k.typeId,
-			BuyPrice:     maxBuyPrice,
-			SellPrice:    minSellPrice,
-			LocationName: extraDataWithName["stations"][int(k.locationId)],
-			SystemName:   extraDataWithName["systems"][ordersMapped[k].systemId],
-			RegionName:   extraDataWithName["regions"][ordersMapped[k].regionId],
-			TypeName:     extraDataWithName["types"][int(k.typeId)],
-			BuyVolume:    totalBuyVolume,
-			SellVolume:   totalSellVolume,
+			RegionId:        regionId,
+			LocationId:      item.LocationId,
+			SystemId:        item.SystemId,
+			TypeId:          item.TypeId,
+			BuyPrice:        item.BuyPrice,
+			SellPrice:       item.SellPrice,
+			LocationName:    extraDataWithName["stations"][item.LocationId],
+			SystemName:      extraDataWithName["systems"][item.SystemId],
+			RegionName:      extraDataWithName["regions"][regionId],
+			TypeName:        extraDataWithName["types"][item.TypeId],
+			BuyVolume:       item.BuyVolume,
+			SellVolume:      item.SellVolume,
+			Spread:          spread,
+			Margin:          margin,
+			IndexedAt:       indexedAt,
+			EsiLastModified: esiLastModified,
 		})
 	}
 
 	log.Infof("Save denormalizedOrders %d", len(denormalizedOrders))
 	denormorder.SaveDenormalizedOrders(regionId, denormalizedOrders, i.client)
 
+	if errSave := indexation.SaveRegionIndexation(regionId, indexation.RegionIndexation{IndexedAt: indexedAt, EsiLastModified: esiLastModified}, i.client); errSave != nil {
+		log.Errorln(errSave)
+	}
+
+	if errNotify := marketwatch.NotifyUpdate(regionId, i.client); errNotify != nil {
+		log.Errorln(errNotify)
+	}
+
 	elapsed := time.Since(start)
 	log.Infof("Indexation end in: %.f seconds", elapsed.Seconds())
-	return 0, 0, nil
+	return nil
+}
+
+// holdUntilEndOfDowntime put back the region in the delayed tasks instead of writing an empty snapshot.
+func (i *Indexer) holdUntilEndOfDowntime(regionId int) {
+	resumeAt := i.esiClient.ResumeAt(i.esiClient.Now())
+	log.Infof("ESI is in downtime, hold region %d until %s", regionId, resumeAt.Format(time.RFC3339))
+
+	i.client.ZAdd(context.Background(), namespace.Key("indexationDelayed"), &goredis.Z{Score: float64(resumeAt.Unix()), Member: regionId})
 }
 
-func (i *Indexer) notifyEndOfIndexation(regionId int) {
+func (i *Indexer) notifyEndOfIndexation(regionId int, status string) {
 	args := goredis.XAddArgs{
-		Stream: "indexationFinished",
-		Values: []interface{}{"regionId", regionId},
+		Stream: namespace.Key("indexationFinished"),
+		Values: []interface{}{"regionId", regionId, "status", status},
 	}
 
 	i.client.XAdd(context.Background(), &args).Result()
@@ -0,0 +1,323 @@
+package alert
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/hyoa/wall-eve/backend/internal/denormorder"
+	"github.com/hyoa/wall-eve/backend/internal/namespace"
+	"github.com/hyoa/wall-eve/backend/internal/searchquery"
+)
+
+const (
+	DefaultCooldown = time.Hour
+	// An alert keep the best matching items only
+	maxMatches       = 100
+	maxHistoryByRule = 100
+	maxFiredEvents   = 10000
+)
+
+// fireScript start the cooldown and record the alert at once, an alert that could not be recorded
+// does not hold the rule in its cooldown.
+var fireScript = goredis.NewScript(`
+if not redis.call('SET', KEYS[1], ARGV[1], 'EX', ARGV[2], 'NX') then
+	return 0
+end
+
+-- A script is not rolled back on error, the cooldown is removed by hand
+local res = redis.pcall('XADD', KEYS[2], 'MAXLEN', '~', ARGV[3], '*', 'fired', ARGV[6])
+if type(res) == 'table' and res.err then
+	redis.call('DEL', KEYS[1])
+	return res
+end
+
+res = redis.pcall('XADD', KEYS[3], 'MAXLEN', '~', ARGV[4], '*', 'ruleId', ARGV[5], 'fired', ARGV[6])
+if type(res) == 'table' and res.err then
+	redis.call('DEL', KEYS[1])
+	return res
+end
+
+return 1
+`)
+
+var (
+	ErrNotFound = errors.New("rule not found")
+	errNoOwner  = errors.New("rule has no owner")
+)
+
+// FieldError is an invalid field of a rule.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Message
+}
+
+// Fields can be compared by a rule, margin is the spread as a percentage of the sell price.
+var Fields = []string{"buyPrice", "sellPrice", "buyVolume", "sellVolume", "spread", "margin"}
+
+var Operators = []string{"<", "<=", ">", ">="}
+
+// Rule fire when the field of an item at the location is compared successfully to the value,
+// eg: sellPrice of typeId 34 at 60003760 < 5 or margin > 20.
+type Rule struct {
+	Id        string    `json:"id"`
+	Owner     string    `json:"owner"`
+	Name      string    `json:"name"`
+	Location  string    `json:"location"`
+	TypeIds   []int     `json:"typeIds"`
+	Field     string    `json:"field"`
+	Operator  string    `json:"operator"`
+	Value     float64   `json:"value"`
+	Cooldown  int       `json:"cooldown"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Fired is an alert in the history of its rule.
+type Fired struct {
+	RuleId   string                          `json:"ruleId"`
+	RuleName string                          `json:"ruleName"`
+	RegionId int                             `json:"regionId"`
+	FiredAt  time.Time                       `json:"firedAt"`
+	Matches  []denormorder.DenormalizedOrder `json:"matches"`
+}
+
+// Validate check the rule and set the default cooldown (in seconds).
+func (r *Rule) Validate() error {
+	if r.Location == "" {
+		return &FieldError{Field: "location", Message: "location is mandatory"}
+	}
+
+	if len(searchquery.Terms(r.Location)) == 0 {
+		return &FieldError{Field: "location", Message: "location must contain a letter or a digit"}
+	}
+
+	if !contains(Fields, r.Field) {
+		return &FieldError{Field: "field", Message: fmt.Sprintf("field must be one of %s", strings.Join(Fields, ", "))}
+	}
+
+	if !contains(Operators, r.Operator) {
+		return &FieldError{Field: "operator", Message: fmt.Sprintf("operator must be one of %s", strings.Join(Operators, ", "))}
+	}
+
+	if math.IsNaN(r.Value) || math.IsInf(r.Value, 0) {
+		return &FieldError{Field: "value", Message: "value must be a number"}
+	}
+
+	for _, typeId := range r.TypeIds {
+		if typeId < 1 {
+			return &FieldError{Field: "typeIds", Message: "typeIds must be positive ids"}
+		}
+	}
+
+	if r.Cooldown < 0 {
+		return &FieldError{Field: "cooldown", Message: "cooldown must be a positive number of seconds"}
+	}
+
+	if r.Cooldown == 0 {
+		r.Cooldown = int(DefaultCooldown.Seconds())
+	}
+
+	return nil
+}
+
+// SaveRule create the rule when it has no id yet, or replace it. A rule belong to the api key of its owner.
+func SaveRule(rule Rule, client *goredis.Client) (Rule, error) {
+	if rule.Owner == "" {
+		return Rule{}, errNoOwner
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	if rule.Id == "" {
+		rule.Id = newId()
+		rule.CreatedAt = now
+	}
+	rule.UpdatedAt = now
+
+	content, _ := json.Marshal(rule)
+
+	pipe := client.TxPipeline()
+	pipe.HSet(context.Background(), namespace.Key("alertRules", rule.Owner), rule.Id, content)
+	pipe.SAdd(context.Background(), namespace.Key("alertRuleOwners"), rule.Owner)
+	_, err := pipe.Exec(context.Background())
+
+	return rule, err
+}
+
+// GetRule return ErrNotFound for the rules of the other owners.
+func GetRule(owner string, ruleId string, client *goredis.Client) (Rule, error) {
+	content, err := client.HGet(context.Background(), namespace.Key("alertRules", owner), ruleId).Result()
+
+	if err == goredis.Nil {
+		return Rule{}, ErrNotFound
+	}
+
+	if err != nil {
+		return Rule{}, err
+	}
+
+	var rule Rule
+	errDecode := json.Unmarshal([]byte(content), &rule)
+
+	return rule, errDecode
+}
+
+// GetRules return the rules of the owner from the oldest to the newest.
+func GetRules(owner string, client *goredis.Client) ([]Rule, error) {
+	values, err := client.HGetAll(context.Background(), namespace.Key("alertRules", owner)).Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]Rule, 0, len(values))
+	for _, content := range values {
+		var rule Rule
+		if errDecode := json.Unmarshal([]byte(content), &rule); errDecode == nil {
+			rules = append(rules, rule)
+		}
+	}
+
+	sort.Slice(rules, func(i, j int) bool { return rules[i].CreatedAt.Before(rules[j].CreatedAt) })
+
+	return rules, nil
+}
+
+// GetAllRules return the rules of every owner, it is used by the alerter only.
+func GetAllRules(client *goredis.Client) ([]Rule, error) {
+	owners, err := client.SMembers(context.Background(), namespace.Key("alertRuleOwners")).Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(owners)
+
+	rules := make([]Rule, 0)
+	for _, owner := range owners {
+		ownerRules, errRules := GetRules(owner, client)
+
+		if errRules != nil {
+			return nil, errRules
+		}
+
+		rules = append(rules, ownerRules...)
+	}
+
+	return rules, nil
+}
+
+// DeleteRule remove the rule with its cooldown and its history.
+func DeleteRule(owner string, ruleId string, client *goredis.Client) error {
+	deleted, err := client.HDel(context.Background(), namespace.Key("alertRules", owner), ruleId).Result()
+
+	if err != nil {
+		return err
+	}
+
+	if deleted == 0 {
+		return ErrNotFound
+	}
+
+	return client.Del(context.Background(), namespace.Key("alertCooldown", ruleId), namespace.Key("alertHistory", ruleId)).Err()
+}
+
+// GetHistory return the last alerts of the rule, the newest first.
+func GetHistory(ruleId string, count int64, client *goredis.Client) ([]Fired, error) {
+	messages, err := client.XRevRangeN(context.Background(), namespace.Key("alertHistory", ruleId), "+", "-", count).Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]Fired, 0, len(messages))
+	for _, message := range messages {
+		if content, ok := message.Values["fired"].(string); ok {
+			var fired Fired
+			if errDecode := json.Unmarshal([]byte(content), &fired); errDecode == nil {
+				history = append(history, fired)
+			}
+		}
+	}
+
+	return history, nil
+}
+
+// Evaluate return the items of the region matching the rule, the best ones first. A 0 region match them all.
+func Evaluate(rule Rule, regionId int, client *goredis.Client) ([]denormorder.DenormalizedOrder, error) {
+	condition := denormorder.Range{Field: rule.Field, Min: math.Inf(-1), Max: math.Inf(1)}
+	sortOrder := "asc"
+
+	switch rule.Operator {
+	case "<":
+		condition.Max, condition.MaxExclusive = rule.Value, true
+	case "<=":
+		condition.Max = rule.Value
+	case ">":
+		condition.Min, condition.MinExclusive = rule.Value, true
+		sortOrder = "desc"
+	case ">=":
+		condition.Min = rule.Value
+		sortOrder = "desc"
+	}
+
+	filter := denormorder.NewFilter(rule.Location)
+	filter.TypeIds = rule.TypeIds
+	filter.Ranges = []denormorder.Range{condition}
+	filter.SortBy = rule.Field
+	filter.SortOrder = sortOrder
+	filter.Limit = maxMatches
+
+	if regionId != 0 {
+		filter.Ranges = append(filter.Ranges, denormorder.Range{Field: "regionId", Min: float64(regionId), Max: float64(regionId)})
+	}
+
+	result, err := denormorder.GetDenormalizedOrdersWithFilter(filter, client)
+
+	return result.Orders, err
+}
+
+// Fire record the alert unless the rule is in its cooldown, it tell if the alert has been recorded.
+func Fire(rule Rule, regionId int, matches []denormorder.DenormalizedOrder, client *goredis.Client) (bool, error) {
+	now := time.Now().UTC().Truncate(time.Second)
+	content, _ := json.Marshal(Fired{RuleId: rule.Id, RuleName: rule.Name, RegionId: regionId, FiredAt: now, Matches: matches})
+
+	keys := []string{
+		namespace.Key("alertCooldown", rule.Id),
+		namespace.Key("alertHistory", rule.Id),
+		namespace.Key("alertFired"),
+	}
+
+	return fireScript.Run(context.Background(), client, keys,
+		now.Unix(), rule.Cooldown, maxHistoryByRule, maxFiredEvents, rule.Id, content).Bool()
+}
+
+func newId() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,190 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/hyoa/wall-eve/backend/internal/namespace"
+)
+
+const (
+	tokenPrefix = "wev_"
+	// The id is the start of the hash, the key can be found without storing it
+	idLength = 16
+)
+
+var ErrNotFound = errors.New("api key not found")
+var ErrRevoked = errors.New("api key revoked")
+
+// ApiKey only keep the hash of the token, the token is shown once when the key is issued.
+type ApiKey struct {
+	Id         string     `json:"id"`
+	Name       string     `json:"name"`
+	Hash       string     `json:"hash"`
+	RateLimit  int        `json:"rateLimit"`
+	DailyQuota int        `json:"dailyQuota"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+}
+
+// Issue create a key with the limits of the default tier when they are 0, it return the token to give to the user.
+func Issue(name string, rateLimit int, dailyQuota int, client *goredis.Client) (ApiKey, string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return ApiKey{}, "", err
+	}
+
+	token := tokenPrefix + hex.EncodeToString(b)
+	hash := hashToken(token)
+
+	if rateLimit == 0 {
+		rateLimit = DefaultTier.RateLimit
+	}
+
+	if dailyQuota == 0 {
+		dailyQuota = DefaultTier.DailyQuota
+	}
+
+	key := ApiKey{
+		Id:         hash[:idLength],
+		Name:       name,
+		Hash:       hash,
+		RateLimit:  rateLimit,
+		DailyQuota: dailyQuota,
+		CreatedAt:  time.Now().UTC().Truncate(time.Second),
+	}
+
+	if err := save(key, client); err != nil {
+		return ApiKey{}, "", err
+	}
+
+	return key, token, nil
+}
+
+// Authenticate return the key of a token, ErrNotFound or ErrRevoked when it can not be used.
+func Authenticate(token string, client *goredis.Client) (ApiKey, error) {
+	if !strings.HasPrefix(token, tokenPrefix) {
+		return ApiKey{}, ErrNotFound
+	}
+
+	hash := hashToken(token)
+	key, err := Get(hash[:idLength], client)
+
+	if err != nil {
+		return ApiKey{}, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(key.Hash), []byte(hash)) != 1 {
+		return ApiKey{}, ErrNotFound
+	}
+
+	if key.RevokedAt != nil {
+		return ApiKey{}, ErrRevoked
+	}
+
+	return key, nil
+}
+
+func Get(id string, client *goredis.Client) (ApiKey, error) {
+	content, err := client.HGet(context.Background(), namespace.Key("apiKeys"), id).Result()
+
+	if err == goredis.Nil {
+		return ApiKey{}, ErrNotFound
+	}
+
+	if err != nil {
+		return ApiKey{}, err
+	}
+
+	var key ApiKey
+	errUnmarshal := json.Unmarshal([]byte(content), &key)
+
+	return key, errUnmarshal
+}
+
+// GetAll return every key, the oldest first.
+func GetAll(client *goredis.Client) ([]ApiKey, error) {
+	values, err := client.HGetAll(context.Background(), namespace.Key("apiKeys")).Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]ApiKey, 0, len(values))
+	for _, content := range values {
+		var key ApiKey
+		if errUnmarshal := json.Unmarshal([]byte(content), &key); errUnmarshal != nil {
+			return nil, errUnmarshal
+		}
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+
+	return keys, nil
+}
+
+// Revoke keep the key, and its usage, but refuse its token.
+func Revoke(id string, client *goredis.Client) (ApiKey, error) {
+	key, err := Get(id, client)
+
+	if err != nil {
+		return ApiKey{}, err
+	}
+
+	if key.RevokedAt == nil {
+		now := time.Now().UTC().Truncate(time.Second)
+		key.RevokedAt = &now
+	}
+
+	return key, save(key, client)
+}
+
+func save(key ApiKey, client *goredis.Client) error {
+	content, errMarshal := json.Marshal(key)
+
+	if errMarshal != nil {
+		return errMarshal
+	}
+
+	return client.HSet(context.Background(), namespace.Key("apiKeys"), key.Id, content).Err()
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// Caller is who make a request, a key or an ip for the anonymous tier.
+type Caller struct {
+	Subject string
+	Tier    Tier
+	Key     ApiKey
+}
+
+// Identify return the caller of a request, the ip is only used without a token.
+func Identify(token string, ip string, client *goredis.Client) (Caller, error) {
+	if token == "" {
+		return Caller{Subject: "ip:" + ip, Tier: AnonymousTier}, nil
+	}
+
+	key, err := Authenticate(token, client)
+
+	if err != nil {
+		return Caller{}, err
+	}
+
+	return Caller{Subject: "key:" + key.Id, Tier: Tier{RateLimit: key.RateLimit, DailyQuota: key.DailyQuota}, Key: key}, nil
+}
@@ -0,0 +1,192 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/hyoa/wall-eve/backend/internal/namespace"
+)
+
+const (
+	// RateWindow is the sliding window of the rate limits
+	RateWindow = time.Minute
+	// The quota of a day is kept as the daily usage for a month
+	quotaTTL  = 31 * 24 * time.Hour
+	dayLayout = "20060102"
+)
+
+// Tier is the limits of a request, the rate limit is by RateWindow and the quota by UTC day.
+type Tier struct {
+	RateLimit  int
+	DailyQuota int
+}
+
+var AnonymousTier = Tier{RateLimit: 30, DailyQuota: 1000}
+var DefaultTier = Tier{RateLimit: 300, DailyQuota: 100000}
+
+// Decision of a request, Retry is how long to wait when it is not allowed.
+type Decision struct {
+	Allowed        bool
+	QuotaExceeded  bool
+	RateLimit      int
+	RateRemaining  int
+	DailyQuota     int
+	QuotaRemaining int
+	Retry          time.Duration
+}
+
+// consumeScript drop the requests out of the window, then count the request if the rate and the quota allow it.
+// It return {allowed, requests in the window, oldest request in the window, requests of the day}.
+var consumeScript = goredis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+
+local count = redis.call('ZCARD', KEYS[1])
+local used = tonumber(redis.call('GET', KEYS[2]) or '0')
+
+if count >= tonumber(ARGV[3]) then
+	local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+	return {0, count, tonumber(oldest[2]), used}
+end
+
+if used >= tonumber(ARGV[4]) then
+	return {0, count, 0, used}
+end
+
+redis.call('ZADD', KEYS[1], ARGV[1], ARGV[5])
+redis.call('PEXPIRE', KEYS[1], window)
+used = redis.call('INCR', KEYS[2])
+redis.call('EXPIRE', KEYS[2], ARGV[6])
+
+return {1, count + 1, 0, used}
+`)
+
+// Consume count a request of the subject (eg: key:{id} or ip:{ip}) unless it is over its rate limit or its quota.
+func Consume(subject string, tier Tier, client *goredis.Client) (Decision, error) {
+	now := time.Now()
+	b := make([]byte, 8)
+	rand.Read(b)
+
+	keys := []string{namespace.Key("rateLimit", subject), quotaKey(subject, now)}
+	res, err := consumeScript.Run(context.Background(), client, keys,
+		now.UnixMilli(), RateWindow.Milliseconds(), tier.RateLimit, tier.DailyQuota,
+		fmt.Sprintf("%d-%s", now.UnixNano(), hex.EncodeToString(b)), int(quotaTTL.Seconds()),
+	).Int64Slice()
+
+	if err != nil {
+		return Decision{}, err
+	}
+
+	decision := Decision{
+		Allowed:        res[0] == 1,
+		RateLimit:      tier.RateLimit,
+		RateRemaining:  remaining(tier.RateLimit, res[1]),
+		DailyQuota:     tier.DailyQuota,
+		QuotaRemaining: remaining(tier.DailyQuota, res[3]),
+	}
+
+	if decision.Allowed {
+		return decision, nil
+	}
+
+	if res[2] != 0 {
+		decision.Retry = time.UnixMilli(res[2]).Add(RateWindow).Sub(now)
+	} else {
+		decision.QuotaExceeded = true
+		year, month, day := now.UTC().Date()
+		decision.Retry = time.Date(year, month, day+1, 0, 0, 0, 0, time.UTC).Sub(now)
+	}
+
+	return decision, nil
+}
+
+// CountUsage add a request to the usage of a key, by route.
+func CountUsage(id string, route string, client *goredis.Client) error {
+	pipe := client.Pipeline()
+	pipe.HIncrBy(context.Background(), namespace.Key("apiKeyUsage", id), "total", 1)
+	pipe.HIncrBy(context.Background(), namespace.Key("apiKeyUsage", id), route, 1)
+	pipe.HSet(context.Background(), namespace.Key("apiKeyUsage", id), "lastUsedAt", time.Now().Unix())
+	_, err := pipe.Exec(context.Background())
+
+	return err
+}
+
+type Usage struct {
+	Total      int64
+	LastUsedAt time.Time
+	ByRoute    map[string]int64
+	// ByDay is the number of requests of the last days, today first
+	ByDay []DayUsage
+}
+
+type DayUsage struct {
+	Day      string
+	Requests int64
+}
+
+// GetUsage read the counters of a key, with the requests of the last days (at most 31).
+func GetUsage(id string, days int, client *goredis.Client) (Usage, error) {
+	values, err := client.HGetAll(context.Background(), namespace.Key("apiKeyUsage", id)).Result()
+
+	if err != nil {
+		return Usage{}, err
+	}
+
+	usage := Usage{ByRoute: make(map[string]int64)}
+	for field, value := range values {
+		v, _ := strconv.ParseInt(value, 10, 64)
+
+		switch field {
+		case "total":
+			usage.Total = v
+		case "lastUsedAt":
+			usage.LastUsedAt = time.Unix(v, 0).UTC()
+		default:
+			usage.ByRoute[field] = v
+		}
+	}
+
+	now := time.Now()
+	keys := make([]string, 0, days)
+	for i := 0; i < days; i++ {
+		keys = append(keys, quotaKey("key:"+id, now.AddDate(0, 0, -i)))
+	}
+
+	if len(keys) == 0 {
+		return usage, nil
+	}
+
+	counts, errCounts := client.MGet(context.Background(), keys...).Result()
+
+	if errCounts != nil {
+		return Usage{}, errCounts
+	}
+
+	for i, count := range counts {
+		var requests int64
+		if val, ok := count.(string); ok {
+			requests, _ = strconv.ParseInt(val, 10, 64)
+		}
+		usage.ByDay = append(usage.ByDay, DayUsage{Day: now.AddDate(0, 0, -i).UTC().Format("2006-01-02"), Requests: requests})
+	}
+
+	return usage, nil
+}
+
+func remaining(limit int, used int64) int {
+	if int64(limit) < used {
+		return 0
+	}
+
+	return limit - int(used)
+}
+
+func quotaKey(subject string, t time.Time) string {
+	return namespace.Key("apiQuota", subject, t.UTC().Format(dayLayout))
+}
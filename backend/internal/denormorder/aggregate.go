@@ -0,0 +1,96 @@
+package denormorder
+
+import (
+	"context"
+	"strconv"
+
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/hyoa/wall-eve/backend/internal/namespace"
+	"github.com/hyoa/wall-eve/backend/internal/searchquery"
+)
+
+const maxGroups = 10000
+
+// GroupCount is the number of documents sharing the same values for the grouped fields.
+type GroupCount struct {
+	Values map[string]int
+	Count  int
+}
+
+func CountByRegion(client *goredis.Client) ([]GroupCount, error) {
+	return countGroupedBy(searchquery.All(), client, "regionId")
+}
+
+func CountBySystemInRegion(regionId int, client *goredis.Client) ([]GroupCount, error) {
+	return countGroupedBy(searchquery.Equal("regionId", regionId), client, "systemId")
+}
+
+func CountByLocationInSystem(systemId int, client *goredis.Client) ([]GroupCount, error) {
+	return countGroupedBy(searchquery.Equal("systemId", systemId), client, "locationId", "regionId")
+}
+
+// RegionsOfLocation return the regions having items at the location, whatever their type, prices or volumes.
+func RegionsOfLocation(location string, client *goredis.Client) ([]int, error) {
+	groups, err := countGroupedBy(locationClause(location), client, "regionId")
+
+	if err != nil {
+		return nil, err
+	}
+
+	regions := make([]int, 0, len(groups))
+	for _, group := range groups {
+		regions = append(regions, group.Values["regionId"])
+	}
+
+	return regions, nil
+}
+
+func countGroupedBy(query searchquery.Clause, client *goredis.Client, fields ...string) ([]GroupCount, error) {
+	args := []interface{}{"FT.AGGREGATE", namespace.Key("denormalizedOrdersIdx"), query.String(), "GROUPBY", len(fields)}
+	for _, field := range fields {
+		args = append(args, "@"+field)
+	}
+	args = append(args, "REDUCE", "COUNT", 0, "AS", "count", "LIMIT", 0, maxGroups)
+
+	val, err := client.Do(context.Background(), args...).Result()
+
+	if err != nil {
+		return make([]GroupCount, 0), err
+	}
+
+	return parseGroupCounts(val), nil
+}
+
+// parseGroupCounts read rows formatted as [field1, value1, ..., "count", n], the first element is the number of rows.
+func parseGroupCounts(data interface{}) []GroupCount {
+	groups := make([]GroupCount, 0)
+
+	rows, ok := data.([]interface{})
+	if !ok {
+		return groups
+	}
+
+	for i := 1; i < len(rows); i++ {
+		row, ok := rows[i].([]interface{})
+		if !ok {
+			continue
+		}
+
+		group := GroupCount{Values: make(map[string]int)}
+		for k := 0; k+1 < len(row); k += 2 {
+			field, _ := row[k].(string)
+			value, _ := row[k+1].(string)
+			v, _ := strconv.Atoi(value)
+
+			if field == "count" {
+				group.Count = v
+			} else {
+				group.Values[field] = v
+			}
+		}
+
+		groups = append(groups, group)
+	}
+
+	return groups
+}
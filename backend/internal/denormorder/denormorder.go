@@ -2,17 +2,29 @@ package denormorder
 
 import (
 	"context"
+	"crypto/sha1"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	goredis "github.com/go-redis/redis/v8"
+	"github.com/hyoa/wall-eve/backend/internal/namespace"
+	"github.com/hyoa/wall-eve/backend/internal/searchquery"
 	"github.com/nitishm/go-rejson/v4"
 	"github.com/panjf2000/ants/v2"
 )
 
+// documentTtl remove the items that are not sold anymore, as a region is indexed more often than that.
+const documentTtl = 24 * time.Hour
+
+var ErrNotFound = errors.New("denormalized order not found")
+
 type DenormalizedOrderRedis struct {
 	RegionId           int     `json:"regionId"`
 	SystemId           int     `json:"systemId"`
@@ -26,8 +38,50 @@ type DenormalizedOrderRedis struct {
 	SellPrice          float64 `json:"sellPrice"`
 	BuyVolume          int     `json:"buyVolume"`
 	SellVolume         int     `json:"sellVolume"`
+	Spread             float64 `json:"spread"`
+	Margin             float64 `json:"margin"`
 	LocationIdTags     string  `json:"locationIdTags"`
 	LocationNameConcat string  `json:"locationNameConcat"`
+	// Unix timestamps, 0 for the documents indexed before they were recorded
+	IndexedAt       int64 `json:"indexedAt"`
+	EsiLastModified int64 `json:"esiLastModified"`
+}
+
+func (o DenormalizedOrderRedis) toDenormalizedOrder() DenormalizedOrder {
+	return DenormalizedOrder{
+		RegionId:        o.RegionId,
+		SystemId:        o.SystemId,
+		LocationId:      o.LocationId,
+		TypeId:          o.TypeId,
+		RegionName:      o.RegionName,
+		SystemName:      o.SystemName,
+		LocationName:    o.LocationName,
+		TypeName:        o.TypeName,
+		BuyPrice:        o.BuyPrice,
+		SellPrice:       o.SellPrice,
+		BuyVolume:       o.BuyVolume,
+		SellVolume:      o.SellVolume,
+		Spread:          o.Spread,
+		Margin:          o.Margin,
+		IndexedAt:       unixTime(o.IndexedAt),
+		EsiLastModified: unixTime(o.EsiLastModified),
+	}
+}
+
+func unixTime(ts int64) time.Time {
+	if ts == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(ts, 0).UTC()
+}
+
+func unixTimestamp(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+
+	return t.Unix()
 }
 
 type DenormalizedOrder struct {
@@ -43,40 +97,295 @@ type DenormalizedOrder struct {
 	SellPrice    float64 `json:"sellPrice"`
 	BuyVolume    int     `json:"buyVolume"`
 	SellVolume   int     `json:"sellVolume"`
+	Spread       float64 `json:"spread"`
+	Margin       float64 `json:"margin"`
+	// IndexedAt is when wall-eve stored the item, EsiLastModified when ESI built the orders snapshot
+	IndexedAt       time.Time `json:"indexedAt"`
+	EsiLastModified time.Time `json:"esiLastModified"`
 }
 
 type Filter struct {
-	MinBuyPrice  float64
-	MaxBuyPrice  float64
-	MinSellPrice float64
-	MaxSellPrice float64
-	TypeName     string
-	Location     string
-}
-
-func GetDenormalizedOrdersWithFilter(filter Filter, client *goredis.Client) ([]DenormalizedOrder, error) {
-	searchParams := createSearchParams(filter)
-	queryParams := fmt.Sprintf(
-		"%s @buyPrice:[%.2f %.2f] @sellPrice:[%.2f %.2f]",
-		searchParams,
-		filter.MinBuyPrice,
-		filter.MaxBuyPrice,
-		filter.MinSellPrice,
-		filter.MaxSellPrice,
-	)
+	MinBuyPrice   float64
+	MaxBuyPrice   float64
+	MinSellPrice  float64
+	MaxSellPrice  float64
+	MinBuyVolume  int64
+	MaxBuyVolume  int64
+	MinSellVolume int64
+	MaxSellVolume int64
+	TypeName      string
+	TypeNameMatch string
+	TypeIds       []int
+	Location      string
+	Limit         int
+	Offset        int
+	SortBy        string
+	SortOrder     string
+	Ranges        []Range
+}
+
+// Normalize return the filter with the values that search the same items written the same way,
+// eg: the location and the type name are not case sensitive and the order of the type ids does not matter.
+func (f Filter) Normalize() Filter {
+	f.Location = strings.Join(searchquery.Terms(f.Location), " ")
+	f.TypeName = strings.Join(TypeNameTerms(f.TypeName), " ")
+
+	if f.TypeName == "" || f.TypeNameMatch == "" {
+		f.TypeNameMatch = TypeNameMatchText
+	}
+
+	if len(f.TypeIds) > 0 {
+		typeIds := make([]int, 0, len(f.TypeIds))
+		seen := make(map[int]bool, len(f.TypeIds))
+		for _, typeId := range f.TypeIds {
+			if !seen[typeId] {
+				seen[typeId] = true
+				typeIds = append(typeIds, typeId)
+			}
+		}
+		sort.Ints(typeIds)
+		f.TypeIds = typeIds
+	}
+
+	if f.SortBy == "" {
+		f.SortOrder = ""
+	} else if f.SortOrder != "desc" {
+		f.SortOrder = "asc"
+	}
+
+	return f
+}
+
+// CacheKey is the same for the filters running the same search.
+func (f Filter) CacheKey() string {
+	sum := sha1.Sum([]byte(fmt.Sprint(searchArgs(f.Normalize(), f.Offset, f.Limit)...)))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// Range restrict any numeric field of the index, an infinite bound is unbounded.
+type Range struct {
+	Field        string
+	Min          float64
+	Max          float64
+	MinExclusive bool
+	MaxExclusive bool
+}
+
+func (r Range) clause() searchquery.Clause {
+	return searchquery.Range(r.Field, searchquery.Bound{Value: r.Min, Exclusive: r.MinExclusive}, searchquery.Bound{Value: r.Max, Exclusive: r.MaxExclusive})
+}
+
+const (
+	TypeNameMatchText   = "text"
+	TypeNameMatchPrefix = "prefix"
+	TypeNameMatchFuzzy  = "fuzzy"
+)
+
+type SearchResult struct {
+	Total  int
+	Orders []DenormalizedOrder
+	// LastModified is the most recent indexation of the regions of the location, it is set by the query cache
+	LastModified time.Time
+}
+
+func GetDenormalizedOrdersWithFilter(filter Filter, client *goredis.Client) (SearchResult, error) {
+	val, err := client.Do(context.Background(), searchArgs(filter, filter.Offset, filter.Limit)...).Result()
+
+	if err != nil {
+		return SearchResult{Orders: make([]DenormalizedOrder, 0)}, err
+	}
+
+	orders := make([]DenormalizedOrder, 0)
+	total := parseSearchOrders(val, func(o DenormalizedOrder) error {
+		orders = append(orders, o)
+		return nil
+	})
+
+	return SearchResult{Total: total, Orders: orders}, nil
+}
+
+// OrderCursor read the orders of the filter window by batches, from a cursor of FT.AGGREGATE. Each document
+// is read once even when the index change during the read, and a batch does not have to skip the documents
+// of the previous ones like an offset.
+type OrderCursor struct {
+	batchSize int
+	client    *goredis.Client
+	rows      interface{}
+	cursorId  int64
+}
 
-	val, err := client.Do(
-		context.Background(),
-		"FT.SEARCH", "denormalizedOrdersIdx",
-		queryParams,
-		"LIMIT", 0, 10000,
-	).Result()
+// OpenOrderCursor run the aggregate of the filter, its first batch is read so an error of the search
+// is known before any order is handed out.
+func OpenOrderCursor(filter Filter, batchSize int, client *goredis.Client) (*OrderCursor, error) {
+	val, err := client.Do(context.Background(), aggregateArgs(filter, batchSize)...).Result()
 
 	if err != nil {
-		return make([]DenormalizedOrder, 0), err
+		return nil, err
+	}
+
+	c := &OrderCursor{batchSize: batchSize, client: client}
+	c.rows, c.cursorId = parseCursor(val)
+
+	return c, nil
+}
+
+// Next return the next batch of orders, an empty batch once every order has been read.
+func (c *OrderCursor) Next() ([]DenormalizedOrder, error) {
+	orders := make([]DenormalizedOrder, 0, c.batchSize)
+
+	for {
+		if c.rows != nil {
+			parseSearchOrders(c.rows, func(o DenormalizedOrder) error {
+				orders = append(orders, o)
+				return nil
+			})
+			c.rows = nil
+		}
+
+		if len(orders) > 0 || c.cursorId == 0 {
+			return orders, nil
+		}
+
+		val, err := c.client.Do(context.Background(), "FT.CURSOR", "READ", namespace.Key("denormalizedOrdersIdx"), c.cursorId, "COUNT", c.batchSize).Result()
+
+		if err != nil {
+			return nil, err
+		}
+
+		c.rows, c.cursorId = parseCursor(val)
 	}
+}
 
-	return parseSearchOrders(val), nil
+// Close delete the cursor when it has not been read to the end, redis would keep it until its timeout.
+func (c *OrderCursor) Close() {
+	if c.cursorId != 0 {
+		c.client.Do(context.Background(), "FT.CURSOR", "DEL", namespace.Key("denormalizedOrdersIdx"), c.cursorId)
+		c.cursorId = 0
+	}
+}
+
+// StreamDenormalizedOrdersWithFilter hand the orders of the filter window one by one to fn, so a large read
+// never hold more than a batch in memory. It stops at the first error of fn.
+func StreamDenormalizedOrdersWithFilter(filter Filter, batchSize int, client *goredis.Client, fn func(o DenormalizedOrder) error) error {
+	cursor, err := OpenOrderCursor(filter, batchSize, client)
+
+	if err != nil {
+		return err
+	}
+	defer cursor.Close()
+
+	for {
+		orders, errNext := cursor.Next()
+
+		if errNext != nil {
+			return errNext
+		}
+
+		if len(orders) == 0 {
+			return nil
+		}
+
+		for _, o := range orders {
+			if errFn := fn(o); errFn != nil {
+				return errFn
+			}
+		}
+	}
+}
+
+// aggregateArgs is the FT.SEARCH of the filter as a pipeline read with a cursor, the documents are loaded as json.
+func aggregateArgs(filter Filter, batchSize int) []interface{} {
+	args := []interface{}{"FT.AGGREGATE", namespace.Key("denormalizedOrdersIdx"), filterQuery(filter).String(), "LOAD", 1, "$"}
+	if filter.SortBy != "" {
+		sortOrder := "ASC"
+		if filter.SortOrder == "desc" {
+			sortOrder = "DESC"
+		}
+		args = append(args, "SORTBY", 2, "@"+filter.SortBy, sortOrder)
+	}
+
+	return append(args, "LIMIT", filter.Offset, filter.Limit, "WITHCURSOR", "COUNT", batchSize)
+}
+
+// parseCursor split a reply of FT.AGGREGATE WITHCURSOR or FT.CURSOR READ into its rows and the next cursor, 0 at the end.
+func parseCursor(data interface{}) (interface{}, int64) {
+	val, ok := data.([]interface{})
+	if !ok || len(val) != 2 {
+		return []interface{}{}, 0
+	}
+
+	cursorId, _ := val[1].(int64)
+
+	return val[0], cursorId
+}
+
+// filterQuery is the query of the filter with its bounds of prices and volumes.
+func filterQuery(filter Filter) searchquery.Clause {
+	return searchquery.And(
+		searchQuery(filter),
+		searchquery.Range("buyPrice", searchquery.Inclusive(filter.MinBuyPrice), searchquery.Inclusive(filter.MaxBuyPrice)),
+		searchquery.Range("sellPrice", searchquery.Inclusive(filter.MinSellPrice), searchquery.Inclusive(filter.MaxSellPrice)),
+		searchquery.Range("buyVolume", searchquery.Inclusive(float64(filter.MinBuyVolume)), searchquery.Inclusive(float64(filter.MaxBuyVolume))),
+		searchquery.Range("sellVolume", searchquery.Inclusive(float64(filter.MinSellVolume)), searchquery.Inclusive(float64(filter.MaxSellVolume))),
+	)
+}
+
+func searchArgs(filter Filter, offset int, limit int) []interface{} {
+	args := []interface{}{"FT.SEARCH", namespace.Key("denormalizedOrdersIdx"), filterQuery(filter).String()}
+	if filter.SortBy != "" {
+		sortOrder := "ASC"
+		if filter.SortOrder == "desc" {
+			sortOrder = "DESC"
+		}
+		args = append(args, "SORTBY", filter.SortBy, sortOrder)
+	}
+	args = append(args, "LIMIT", offset, limit)
+
+	return args
+}
+
+type Freshness struct {
+	IndexedAt       time.Time `json:"indexedAt"`
+	EsiLastModified time.Time `json:"esiLastModified"`
+	ExpiresAt       time.Time `json:"expiresAt"`
+}
+
+// GetDenormalizedOrder read a single item at a location, its expiration is deduced from the ttl of the document.
+func GetDenormalizedOrder(locationId int, typeId int, client *goredis.Client) (DenormalizedOrder, Freshness, error) {
+	key := namespace.Key("denormalizedOrders", locationId, typeId)
+
+	rh := rejson.NewReJSONHandler()
+	rh.SetGoRedisClient(client)
+
+	res, errGet := rh.JSONGet(key, ".")
+
+	if errGet == goredis.Nil {
+		return DenormalizedOrder{}, Freshness{}, ErrNotFound
+	}
+
+	if errGet != nil {
+		return DenormalizedOrder{}, Freshness{}, errGet
+	}
+
+	var denormOrderRedis DenormalizedOrderRedis
+	if errDecode := json.Unmarshal(res.([]byte), &denormOrderRedis); errDecode != nil {
+		return DenormalizedOrder{}, Freshness{}, errDecode
+	}
+
+	var freshness Freshness
+	if ttl, errTtl := client.TTL(context.Background(), key).Result(); errTtl == nil && ttl > 0 {
+		freshness.ExpiresAt = time.Now().Add(ttl).UTC().Truncate(time.Second)
+		freshness.IndexedAt = freshness.ExpiresAt.Add(-documentTtl)
+	}
+
+	order := denormOrderRedis.toDenormalizedOrder()
+	if !order.IndexedAt.IsZero() {
+		freshness.IndexedAt = order.IndexedAt
+	}
+	freshness.EsiLastModified = order.EsiLastModified
+
+	return order, freshness, nil
 }
 
 func SaveDenormalizedOrders(regionId int, orders []DenormalizedOrder, client *goredis.Client) error {
@@ -114,6 +423,17 @@ func SaveDenormalizedOrders(regionId int, orders []DenormalizedOrder, client *go
 	return nil
 }
 
+// ComputeSpread return the spread and the margin (as percentage of the sell price), both are 0 when one side of the market is empty.
+func ComputeSpread(buyPrice float64, sellPrice float64) (float64, float64) {
+	if buyPrice == 0 || sellPrice == 0 {
+		return 0, 0
+	}
+
+	spread := sellPrice - buyPrice
+
+	return spread, spread / sellPrice * 100
+}
+
 func taskSaveDenormalizedOrderHandler(data interface{}) {
 	t := data.(*taskSaveDenormalizedOrderPayload)
 	t.save()
@@ -129,7 +449,7 @@ type taskSaveDenormalizedOrderPayload struct {
 }
 
 func (t *taskSaveDenormalizedOrderPayload) save() {
-	key := fmt.Sprintf("denormalizedOrders:%d:%d", t.order.LocationId, t.order.TypeId)
+	key := namespace.Key("denormalizedOrders", t.order.LocationId, t.order.TypeId)
 
 	denormOrderRedis := DenormalizedOrderRedis{
 		RegionId:           t.order.RegionId,
@@ -144,8 +464,12 @@ func (t *taskSaveDenormalizedOrderPayload) save() {
 		SellPrice:          t.order.SellPrice,
 		BuyVolume:          t.order.BuyVolume,
 		SellVolume:         t.order.SellVolume,
+		Spread:             t.order.Spread,
+		Margin:             t.order.Margin,
 		LocationIdTags:     fmt.Sprintf("%d, %d, %d", t.order.RegionId, t.order.SystemId, t.order.LocationId),
 		LocationNameConcat: fmt.Sprintf("%s, %s, %s", t.order.RegionName, t.order.SystemName, t.order.LocationName),
+		IndexedAt:          unixTimestamp(t.order.IndexedAt),
+		EsiLastModified:    unixTimestamp(t.order.EsiLastModified),
 	}
 
 	res, errSet := t.rh.JSONSet(key, ".", denormOrderRedis)
@@ -153,7 +477,7 @@ func (t *taskSaveDenormalizedOrderPayload) save() {
 	if errSet != nil || res.(string) != "OK" {
 		t.err = true
 	} else {
-		t.client.Expire(context.Background(), key, 24*time.Hour)
+		t.client.Expire(context.Background(), key, documentTtl)
 		t.err = false
 		t.key = key
 	}
@@ -161,62 +485,95 @@ func (t *taskSaveDenormalizedOrderPayload) save() {
 	t.wg.Done()
 }
 
-func createSearchParams(filter Filter) string {
-	if locationInt, err := strconv.Atoi(filter.Location); err == nil {
-		return fmt.Sprintf("@locationIdTags:{%d}", locationInt)
+// searchQuery match the location, the type names and ids and the ranges of the filter.
+func searchQuery(filter Filter) searchquery.Clause {
+	clauses := make([]searchquery.Clause, 0)
+
+	clauses = append(clauses, locationClause(filter.Location))
+
+	switch filter.TypeNameMatch {
+	case TypeNameMatchPrefix:
+		clauses = append(clauses, searchquery.Text("typeName", words(TypeNameTerms(filter.TypeName), searchquery.Prefix)...))
+	case TypeNameMatchFuzzy:
+		clauses = append(clauses, searchquery.Text("typeName", words(TypeNameTerms(filter.TypeName), searchquery.Fuzzy)...))
+	default:
+		clauses = append(clauses, searchquery.Text("typeName", words(TypeNameTerms(filter.TypeName), searchquery.Word)...))
 	}
 
-	return fmt.Sprintf("@locationNameConcat:(%s)", filter.Location)
+	typeIds := make([]searchquery.Clause, 0, len(filter.TypeIds))
+	for _, typeId := range filter.TypeIds {
+		typeIds = append(typeIds, searchquery.Equal("typeId", typeId))
+	}
+	clauses = append(clauses, searchquery.Or(typeIds...))
+
+	for _, r := range filter.Ranges {
+		clauses = append(clauses, r.clause())
+	}
+
+	return searchquery.And(clauses...)
 }
 
-func parseSearchOrders(data interface{}) []DenormalizedOrder {
-	orders := make([]DenormalizedOrderRedis, 0)
+// locationClause match the items of a region, a system or a location by its id or its name. Without location
+// the whole universe is searched.
+func locationClause(location string) searchquery.Clause {
+	if locationId, err := strconv.Atoi(strings.TrimSpace(location)); err == nil {
+		return searchquery.Tag("locationIdTags", strconv.Itoa(locationId))
+	}
 
-	elements := make([]interface{}, 0)
+	if location == "" {
+		return searchquery.All()
+	}
 
-	// Remove counter
-	switch val := data.(type) {
-	case []interface{}:
-		for i := 1; i < len(val); i++ {
-			elements = append(elements, val[i])
-		}
-	default:
+	return searchquery.Text("locationNameConcat", words(searchquery.Terms(location), searchquery.Word)...)
+}
+
+func words(terms []string, word func(value string) searchquery.Term) []searchquery.Term {
+	result := make([]searchquery.Term, 0, len(terms))
+	for _, term := range terms {
+		result = append(result, word(term))
+	}
+
+	return result
+}
+
+// TypeNameTerms split a type name like the index tokenize it.
+func TypeNameTerms(typeName string) []string {
+	return searchquery.Terms(typeName)
+}
+
+// parseSearchOrders hand every order of a FT.SEARCH reply to fn, until it returns an error, and return the total.
+func parseSearchOrders(data interface{}, fn func(o DenormalizedOrder) error) int {
+	total := 0
+
+	val, ok := data.([]interface{})
+	if !ok {
 		panic("Wrong element")
 	}
 
-	for k := range elements {
-		switch val := elements[k].(type) {
-		case []interface{}:
-			for k2 := range val {
-				switch val2 := val[k2].(type) {
-				case string:
-					if val2 != "$" {
-						var denormalizedOrderRedis DenormalizedOrderRedis
-						json.Unmarshal([]byte(val2), &denormalizedOrderRedis)
-						orders = append(orders, denormalizedOrderRedis)
-					}
+	// Extract counter
+	if len(val) > 0 {
+		if count, ok := val[0].(int64); ok {
+			total = int(count)
+		}
+	}
+
+	for i := 1; i < len(val); i++ {
+		fields, ok := val[i].([]interface{})
+		if !ok {
+			continue
+		}
+
+		for k := range fields {
+			if field, ok := fields[k].(string); ok && field != "$" {
+				var denormalizedOrderRedis DenormalizedOrderRedis
+				json.Unmarshal([]byte(field), &denormalizedOrderRedis)
+
+				if errFn := fn(denormalizedOrderRedis.toDenormalizedOrder()); errFn != nil {
+					return total
 				}
 			}
 		}
 	}
 
-	denormOrders := make([]DenormalizedOrder, 0)
-	for k := range orders {
-		denormOrders = append(denormOrders, DenormalizedOrder{
-			RegionId:     orders[k].RegionId,
-			SystemId:     orders[k].SystemId,
-			LocationId:   orders[k].LocationId,
-			TypeId:       orders[k].TypeId,
-			RegionName:   orders[k].RegionName,
-			SystemName:   orders[k].SystemName,
-			LocationName: orders[k].LocationName,
-			TypeName:     orders[k].TypeName,
-			BuyPrice:     orders[k].BuyPrice,
-			SellPrice:    orders[k].SellPrice,
-			BuyVolume:    orders[k].BuyVolume,
-			SellVolume:   orders[k].SellVolume,
-		})
-	}
-
-	return denormOrders
+	return total
 }
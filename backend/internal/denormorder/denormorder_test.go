@@ -0,0 +1,56 @@
+package denormorder
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestAggregateArgs(t *testing.T) {
+	filter := NewFilter("jita")
+	filter.Limit = 100000
+	filter.Offset = 10
+	filter.SortBy = "margin"
+	filter.SortOrder = "desc"
+
+	got := fmt.Sprint(aggregateArgs(filter, 1000)...)
+	search := searchArgs(filter, filter.Offset, filter.Limit)
+	want := fmt.Sprint("FT.AGGREGATE", search[1], search[2], "LOAD", 1, "$", "SORTBY", 2, "@margin", "DESC", "LIMIT", 10, 100000, "WITHCURSOR", "COUNT", 1000)
+
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestParseCursor(t *testing.T) {
+	row := []interface{}{"$", `{"regionId":10000002,"typeId":34,"typeName":"Tritanium"}`}
+
+	tests := []struct {
+		name       string
+		reply      interface{}
+		wantOrders int
+		wantCursor int64
+	}{
+		{"first batch", []interface{}{[]interface{}{int64(2), row, row}, int64(42)}, 2, 42},
+		{"last batch", []interface{}{[]interface{}{int64(2), row}, int64(0)}, 1, 0},
+		{"unexpected reply", "OK", 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, cursorId := parseCursor(tt.reply)
+
+			orders := 0
+			parseSearchOrders(rows, func(o DenormalizedOrder) error {
+				if o.TypeId != 34 {
+					t.Errorf("got type %d, want 34", o.TypeId)
+				}
+				orders++
+				return nil
+			})
+
+			if orders != tt.wantOrders || cursorId != tt.wantCursor {
+				t.Errorf("got %d orders and cursor %d, want %d and %d", orders, cursorId, tt.wantOrders, tt.wantCursor)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package denormorder
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hyoa/wall-eve/backend/internal/namespace"
+)
+
+type IndexField struct {
+	Path      string
+	Name      string
+	Type      string
+	Sortable  bool
+	Separator string
+}
+
+// IndexSchema is the only definition of the denormalizedOrdersIdx fields, used by the install
+// command and printed by the schema command for the README.
+var IndexSchema = []IndexField{
+	{Path: "$.regionId", Name: "regionId", Type: "NUMERIC", Sortable: true},
+	{Path: "$.systemId", Name: "systemId", Type: "NUMERIC", Sortable: true},
+	{Path: "$.locationId", Name: "locationId", Type: "NUMERIC", Sortable: true},
+	{Path: "$.typeId", Name: "typeId", Type: "NUMERIC"},
+	{Path: "$.buyPrice", Name: "buyPrice", Type: "NUMERIC", Sortable: true},
+	{Path: "$.sellPrice", Name: "sellPrice", Type: "NUMERIC", Sortable: true},
+	{Path: "$.buyVolume", Name: "buyVolume", Type: "NUMERIC", Sortable: true},
+	{Path: "$.sellVolume", Name: "sellVolume", Type: "NUMERIC", Sortable: true},
+	{Path: "$.spread", Name: "spread", Type: "NUMERIC", Sortable: true},
+	{Path: "$.margin", Name: "margin", Type: "NUMERIC", Sortable: true},
+	{Path: "$.locationName", Name: "locationName", Type: "TEXT"},
+	{Path: "$.systemName", Name: "systemName", Type: "TEXT"},
+	{Path: "$.regionName", Name: "regionName", Type: "TEXT"},
+	{Path: "$.typeName", Name: "typeName", Type: "TEXT", Sortable: true},
+	{Path: "$.locationNameConcat", Name: "locationNameConcat", Type: "TEXT"},
+	{Path: "$.locationIdTags", Name: "locationIdTags", Type: "TAG", Separator: ","},
+}
+
+// SortableFields can be used in Filter.SortBy, they must be SORTABLE in the index. The ids are
+// SORTABLE only to be grouped by the discovery endpoints.
+var SortableFields = []string{"buyPrice", "sellPrice", "buyVolume", "sellVolume", "spread", "margin", "typeName"}
+
+func (f IndexField) args() []interface{} {
+	args := []interface{}{f.Path, "AS", f.Name, f.Type}
+
+	if f.Separator != "" {
+		args = append(args, "SEPARATOR", f.Separator)
+	}
+
+	if f.Sortable {
+		args = append(args, "SORTABLE")
+	}
+
+	return args
+}
+
+// IndexCreateArgs return the FT.CREATE command of the index, in the namespace.
+func IndexCreateArgs() []interface{} {
+	args := []interface{}{
+		"FT.CREATE", namespace.Key("denormalizedOrdersIdx"),
+		"ON", "JSON",
+		"PREFIX", "1", namespace.Key("denormalizedOrders:"),
+		"SCHEMA",
+	}
+
+	for _, field := range IndexSchema {
+		args = append(args, field.args()...)
+	}
+
+	return args
+}
+
+// IndexCreateCommand format IndexCreateArgs to be pasted in redis-cli.
+func IndexCreateCommand() string {
+	args := IndexCreateArgs()
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s %s\n    %s %s\n    %s %s %s\n    %s\n", args[0], args[1], args[2], args[3], args[4], args[5], args[6], args[7]))
+
+	for _, field := range IndexSchema {
+		parts := make([]string, 0)
+		for _, arg := range field.args() {
+			if arg == field.Separator {
+				arg = fmt.Sprintf("%q", arg)
+			}
+			parts = append(parts, fmt.Sprintf("%v", arg))
+		}
+
+		b.WriteString(fmt.Sprintf("        %s\n", strings.Join(parts, " ")))
+	}
+
+	return b.String()
+}
@@ -0,0 +1,122 @@
+package denormorder
+
+import (
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hyoa/wall-eve/backend/internal/namespace"
+	"gopkg.in/yaml.v2"
+)
+
+type swaggerDoc struct {
+	Paths map[string]struct {
+		Get struct {
+			Parameters []struct {
+				Name   string
+				Schema struct {
+					Enum []string
+				}
+			}
+		}
+	}
+	Components struct {
+		Schemas map[string]struct {
+			Properties map[string]struct {
+				Type string
+			}
+		}
+	}
+}
+
+// TestSwaggerMatchIndexSchema check that the fields of the index written by hand in the swagger are
+// the ones of IndexSchema: the sortable fields of /market and the indexed fields of an item.
+func TestSwaggerMatchIndexSchema(t *testing.T) {
+	content, errRead := os.ReadFile("../../../swagger/wall-eve.yaml")
+
+	if errRead != nil {
+		t.Fatal(errRead)
+	}
+
+	var doc swaggerDoc
+	if errParse := yaml.Unmarshal(content, &doc); errParse != nil {
+		t.Fatal(errParse)
+	}
+
+	var sortBy []string
+	for _, parameter := range doc.Paths["/market"].Get.Parameters {
+		if parameter.Name == "sortBy" {
+			sortBy = parameter.Schema.Enum
+		}
+	}
+
+	sortable := make([]string, 0)
+	for _, field := range IndexSchema {
+		if field.Sortable && isSortable(field.Name) {
+			sortable = append(sortable, field.Name)
+		}
+	}
+
+	sort.Strings(sortBy)
+	sort.Strings(sortable)
+	if !reflect.DeepEqual(sortBy, sortable) || len(sortable) != len(SortableFields) {
+		t.Errorf("got sortBy %v in the swagger, want %v", sortBy, sortable)
+	}
+
+	// The fields only used to search, eg: locationNameConcat, are not returned
+	returned := make(map[string]bool)
+	orderType := reflect.TypeOf(DenormalizedOrder{})
+	for i := 0; i < orderType.NumField(); i++ {
+		returned[strings.Split(orderType.Field(i).Tag.Get("json"), ",")[0]] = true
+	}
+
+	properties := doc.Components.Schemas["MarketItem"].Properties
+	for _, field := range IndexSchema {
+		if !returned[field.Name] {
+			continue
+		}
+
+		property, ok := properties[field.Name]
+		if !ok {
+			t.Errorf("field %s of the index is missing from MarketItem", field.Name)
+			continue
+		}
+
+		wantTypes := map[string][]string{"NUMERIC": {"integer", "number"}, "TEXT": {"string"}, "TAG": {"string"}}[field.Type]
+		if !contains(wantTypes, property.Type) {
+			t.Errorf("got type %s for %s in MarketItem, want one of %v for a %s field", property.Type, field.Name, wantTypes, field.Type)
+		}
+	}
+}
+
+// TestReadmeMatchIndexSchema check that the FT.CREATE of the README is the output of the schema command.
+func TestReadmeMatchIndexSchema(t *testing.T) {
+	if namespace.Prefix() != "" {
+		t.Skip("the README has the keys without namespace")
+	}
+
+	content, errRead := os.ReadFile("../../../README.md")
+
+	if errRead != nil {
+		t.Fatal(errRead)
+	}
+
+	readme := string(content)
+	command := IndexCreateCommand()
+
+	if count := strings.Count(readme, "FT.CREATE denormalizedOrdersIdx\n"); count == 0 || strings.Count(readme, command) != count {
+		t.Errorf("the FT.CREATE of the README are not all the output of the schema command:\n%s", command)
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,151 @@
+package denormorder
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/hyoa/wall-eve/backend/internal/searchquery"
+)
+
+// The bounds of the values of a filter, the same for every api.
+const (
+	MaxPrice          = 1000000000000
+	MaxTypeNameLength = 100
+	MaxTypeIds        = 100
+	// MaxVolume does not fit in the int of a 32 bits target, the volumes of a filter are int64
+	MaxVolume int64 = 1000000000000000
+)
+
+// FilterError is an invalid value of a filter, the field is named like the query parameter of /market.
+type FilterError struct {
+	Field   string
+	Message string
+}
+
+func (e *FilterError) Error() string {
+	return e.Message
+}
+
+// NewFilter return a filter of the location that does not restrict the prices and the volumes.
+func NewFilter(location string) Filter {
+	return Filter{
+		Location:      location,
+		MaxBuyPrice:   MaxPrice,
+		MaxSellPrice:  MaxPrice,
+		MaxBuyVolume:  MaxVolume,
+		MaxSellVolume: MaxVolume,
+		TypeNameMatch: TypeNameMatchText,
+	}
+}
+
+// Validate check the filter given to the REST, gRPC and GraphQL apis, it return a *FilterError.
+// The maximum of the limit is not checked, it depend of the api.
+func (f Filter) Validate() error {
+	if f.Location == "" {
+		return &FilterError{Field: "location", Message: "location is mandatory"}
+	}
+
+	if len(searchquery.Terms(f.Location)) == 0 {
+		return &FilterError{Field: "location", Message: "location must contain a letter or a digit"}
+	}
+
+	if f.TypeName != "" && (len(f.TypeName) > MaxTypeNameLength || len(TypeNameTerms(f.TypeName)) == 0) {
+		return &FilterError{Field: "typeName", Message: fmt.Sprintf("typeName must contain a letter or a digit and at most %d characters", MaxTypeNameLength)}
+	}
+
+	switch f.TypeNameMatch {
+	case "", TypeNameMatchText, TypeNameMatchFuzzy:
+	case TypeNameMatchPrefix:
+		for _, term := range TypeNameTerms(f.TypeName) {
+			if len(term) < 2 {
+				return &FilterError{Field: "typeName", Message: "typeName must have terms of at least 2 characters for a prefix search"}
+			}
+		}
+	default:
+		return &FilterError{Field: "typeNameMatch", Message: fmt.Sprintf("typeNameMatch must be one of %s, %s, %s", TypeNameMatchText, TypeNameMatchPrefix, TypeNameMatchFuzzy)}
+	}
+
+	if len(f.TypeIds) > MaxTypeIds {
+		return &FilterError{Field: "typeId", Message: fmt.Sprintf("typeId accept at most %d values", MaxTypeIds)}
+	}
+
+	for _, typeId := range f.TypeIds {
+		if typeId < 1 {
+			return &FilterError{Field: "typeId", Message: "typeId must be positive ids"}
+		}
+	}
+
+	if f.SortBy != "" && !isSortable(f.SortBy) {
+		return &FilterError{Field: "sortBy", Message: fmt.Sprintf("sortBy must be one of %s", strings.Join(SortableFields, ", "))}
+	}
+
+	if f.SortOrder != "" && f.SortOrder != "asc" && f.SortOrder != "desc" {
+		return &FilterError{Field: "order", Message: "order must be one of asc, desc"}
+	}
+
+	if f.Limit < 1 {
+		return &FilterError{Field: "limit", Message: "limit must be a positive integer"}
+	}
+
+	if f.Offset < 0 {
+		return &FilterError{Field: "offset", Message: "offset must be a positive integer or 0"}
+	}
+
+	prices := []struct {
+		minName, maxName string
+		min, max         float64
+	}{
+		{"minBuyPrice", "maxBuyPrice", f.MinBuyPrice, f.MaxBuyPrice},
+		{"minSellPrice", "maxSellPrice", f.MinSellPrice, f.MaxSellPrice},
+	}
+
+	for _, price := range prices {
+		if err := validateBounds(price.minName, price.min, price.maxName, price.max, MaxPrice); err != nil {
+			return err
+		}
+	}
+
+	volumes := []struct {
+		minName, maxName string
+		min, max         int64
+	}{
+		{"minBuyVolume", "maxBuyVolume", f.MinBuyVolume, f.MaxBuyVolume},
+		{"minSellVolume", "maxSellVolume", f.MinSellVolume, f.MaxSellVolume},
+	}
+
+	for _, volume := range volumes {
+		if err := validateBounds(volume.minName, float64(volume.min), volume.maxName, float64(volume.max), float64(MaxVolume)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateBounds(minName string, min float64, maxName string, max float64, limit float64) error {
+	for _, bound := range []struct {
+		name  string
+		value float64
+	}{{minName, min}, {maxName, max}} {
+		if math.IsNaN(bound.value) || bound.value < 0 || bound.value > limit {
+			return &FilterError{Field: bound.name, Message: fmt.Sprintf("%s must be a number between 0 and %.0f", bound.name, limit)}
+		}
+	}
+
+	if min > max {
+		return &FilterError{Field: minName, Message: fmt.Sprintf("%s must be lower than or equal to %s", minName, maxName)}
+	}
+
+	return nil
+}
+
+func isSortable(field string) bool {
+	for _, sortable := range SortableFields {
+		if field == sortable {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,55 @@
+package denormorder
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestFilterValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		change    func(f *Filter)
+		wantField string
+	}{
+		{"valid", func(f *Filter) {}, ""},
+		{"missing location", func(f *Filter) { f.Location = "" }, "location"},
+		{"location without term", func(f *Filter) { f.Location = "---" }, "location"},
+		{"type name without term", func(f *Filter) { f.TypeName = "()" }, "typeName"},
+		{"short prefix", func(f *Filter) { f.TypeName = "t"; f.TypeNameMatch = TypeNameMatchPrefix }, "typeName"},
+		{"unknown match", func(f *Filter) { f.TypeNameMatch = "regex" }, "typeNameMatch"},
+		{"zero type id", func(f *Filter) { f.TypeIds = []int{34, 0} }, "typeId"},
+		{"unknown sort field", func(f *Filter) { f.SortBy = "regionName" }, "sortBy"},
+		{"unknown order", func(f *Filter) { f.SortOrder = "up" }, "order"},
+		{"zero limit", func(f *Filter) { f.Limit = 0 }, "limit"},
+		{"negative offset", func(f *Filter) { f.Offset = -1 }, "offset"},
+		{"negative price", func(f *Filter) { f.MinBuyPrice = -1 }, "minBuyPrice"},
+		{"nan price", func(f *Filter) { f.MaxSellPrice = math.NaN() }, "maxSellPrice"},
+		{"price above max", func(f *Filter) { f.MaxBuyPrice = MaxPrice + 1 }, "maxBuyPrice"},
+		{"price min above max", func(f *Filter) { f.MinSellPrice, f.MaxSellPrice = 10, 5 }, "minSellPrice"},
+		{"negative volume", func(f *Filter) { f.MinSellVolume = -1 }, "minSellVolume"},
+		{"volume min above max", func(f *Filter) { f.MinBuyVolume, f.MaxBuyVolume = 10, 5 }, "minBuyVolume"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewFilter("jita")
+			filter.Limit = 100
+			tt.change(&filter)
+
+			err := filter.Validate()
+
+			if tt.wantField == "" {
+				if err != nil {
+					t.Fatalf("unexpected error %v", err)
+				}
+				return
+			}
+
+			var errFilter *FilterError
+			if !errors.As(err, &errFilter) || errFilter.Field != tt.wantField {
+				t.Errorf("got %v, want an error on %s", err, tt.wantField)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package esi
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	ModeLive   = ""
+	ModeRecord = "record"
+	ModeReplay = "replay"
+)
+
+// recordedResponse is the content of a cassette file, one per response.
+type recordedResponse struct {
+	Method     string      `json:"method"`
+	Url        string      `json:"url"`
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header"`
+	Body       string      `json:"body"`
+}
+
+// cassette name the files of a directory: the same request made several times is stored
+// under an increasing sequence, so a replay serve the responses in the recorded order.
+type cassette struct {
+	dir       string
+	mu        sync.Mutex
+	sequences map[string]int
+}
+
+func newCassette(dir string) *cassette {
+	return &cassette{
+		dir:       dir,
+		sequences: make(map[string]int),
+	}
+}
+
+// next return the key and the sequence of the next occurrence of the request. The base url
+// is not part of the key so a recording can be replayed against any ESI address.
+func (c *cassette) next(req *http.Request) (string, int) {
+	hash := sha1.Sum([]byte(req.Method + " " + req.URL.RequestURI()))
+	key := hex.EncodeToString(hash[:])
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sequence := c.sequences[key]
+	c.sequences[key]++
+
+	return key, sequence
+}
+
+func (c *cassette) file(key string, sequence int) string {
+	return filepath.Join(c.dir, fmt.Sprintf("%s-%04d.json", key, sequence))
+}
+
+type recordTransport struct {
+	cassette *cassette
+	next     http.RoundTripper
+}
+
+func newRecordTransport(dir string, next http.RoundTripper) (*recordTransport, error) {
+	if errDir := os.MkdirAll(dir, 0755); errDir != nil {
+		return nil, errDir
+	}
+
+	return &recordTransport{cassette: newCassette(dir), next: next}, nil
+}
+
+func (t *recordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, errDo := t.next.RoundTrip(req)
+
+	if errDo != nil {
+		return nil, errDo
+	}
+
+	body, errBody := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if errBody != nil {
+		return nil, errBody
+	}
+
+	content, _ := json.Marshal(recordedResponse{
+		Method:     req.Method,
+		Url:        req.URL.String(),
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       string(body),
+	})
+
+	if errWrite := t.write(req, content); errWrite != nil {
+		return nil, fmt.Errorf("unable to record response for url %s: %w", req.URL.String(), errWrite)
+	}
+
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	return resp, nil
+}
+
+// write never overwrite a file, several processes can record in the same directory.
+func (t *recordTransport) write(req *http.Request, content []byte) error {
+	for {
+		f, errOpen := os.OpenFile(t.cassette.file(t.cassette.next(req)), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+
+		if errors.Is(errOpen, os.ErrExist) {
+			continue
+		}
+
+		if errOpen != nil {
+			return errOpen
+		}
+
+		_, errWrite := f.Write(content)
+		errClose := f.Close()
+
+		if errWrite != nil {
+			return errWrite
+		}
+
+		return errClose
+	}
+}
+
+type replayTransport struct {
+	cassette *cassette
+}
+
+func newReplayTransport(dir string) (*replayTransport, error) {
+	if _, errDir := os.Stat(dir); errDir != nil {
+		return nil, errDir
+	}
+
+	return &replayTransport{cassette: newCassette(dir)}, nil
+}
+
+// RoundTrip serve the recorded responses in order, once they are exhausted the last one is served again.
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key, sequence := t.cassette.next(req)
+	file := t.cassette.file(key, sequence)
+	content, errRead := ioutil.ReadFile(file)
+
+	for errRead != nil && errors.Is(errRead, os.ErrNotExist) && sequence > 0 {
+		sequence--
+		file = t.cassette.file(key, sequence)
+		content, errRead = ioutil.ReadFile(file)
+	}
+
+	if errRead != nil {
+		return nil, fmt.Errorf("no recorded response for %s %s: %w", req.Method, req.URL.RequestURI(), errRead)
+	}
+
+	var recorded recordedResponse
+	if errDecode := json.Unmarshal(content, &recorded); errDecode != nil {
+		return nil, fmt.Errorf("invalid recorded response %s: %w", file, errDecode)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        recorded.Header,
+		Body:          ioutil.NopCloser(bytes.NewReader([]byte(recorded.Body))),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,113 @@
+package esi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	downtimeStart = 11 * time.Hour
+	downtimeEnd   = 11*time.Hour + 30*time.Minute
+	// After the window, the status is checked until this time of day as the server can restart late
+	statusCheckEnd      = 13 * time.Hour
+	statusCacheDuration = 30 * time.Second
+	statusTimeout       = 10 * time.Second
+	// Delay before checking again when ESI is down outside of the window
+	unavailableRetryDelay = time.Minute
+)
+
+type Status struct {
+	Players       int    `json:"players"`
+	ServerVersion string `json:"server_version"`
+	StartTime     string `json:"start_time"`
+	Vip           bool   `json:"vip"`
+}
+
+type statusCache struct {
+	mu        sync.Mutex
+	available bool
+	checkedAt time.Time
+}
+
+// InDowntimeWindow tell if t is during the daily downtime of the game (11:00 to 11:30 UTC).
+func InDowntimeWindow(t time.Time) bool {
+	offset := timeOfDay(t)
+
+	return offset >= downtimeStart && offset < downtimeEnd
+}
+
+// DowntimeEnd return the end of the downtime window of the day of t.
+func DowntimeEnd(t time.Time) time.Time {
+	t = t.UTC()
+
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Add(downtimeEnd)
+}
+
+func timeOfDay(t time.Time) time.Duration {
+	t = t.UTC()
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+}
+
+// Status call /status/ once, without retry, to know if the server is up.
+func (c *Client) Status(ctx context.Context) (Status, error) {
+	ctx, cancel := context.WithTimeout(ctx, statusTimeout)
+	defer cancel()
+
+	var status Status
+	errStatus := c.attempt(ctx, http.MethodGet, "status", nil, func(resp *http.Response) error {
+		return json.NewDecoder(resp.Body).Decode(&status)
+	})
+
+	return status, errStatus
+}
+
+// IsAvailable tell if ESI can be called: never during the downtime window, and after it
+// only once /status/ answer and the server left the VIP mode.
+func (c *Client) IsAvailable(ctx context.Context) bool {
+	now := c.Now()
+
+	if InDowntimeWindow(now) {
+		return false
+	}
+
+	if offset := timeOfDay(now); offset < downtimeStart || offset >= statusCheckEnd {
+		return true
+	}
+
+	c.status.mu.Lock()
+	defer c.status.mu.Unlock()
+
+	if now.Sub(c.status.checkedAt) < statusCacheDuration {
+		return c.status.available
+	}
+
+	status, errStatus := c.Status(ctx)
+	c.status.available = errStatus == nil && !status.Vip
+	c.status.checkedAt = now
+
+	if !c.status.available {
+		log.Warnln("ESI is not available yet after downtime")
+	}
+
+	return c.status.available
+}
+
+// Now return the time of the clock of the client, the workers use it for every downtime computation.
+func (c *Client) Now() time.Time {
+	return c.clock()
+}
+
+// ResumeAt return when ESI should be checked again after IsAvailable returned false.
+func (c *Client) ResumeAt(now time.Time) time.Time {
+	if InDowntimeWindow(now) {
+		return DowntimeEnd(now)
+	}
+
+	return now.Add(unavailableRetryDelay)
+}
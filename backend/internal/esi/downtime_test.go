@@ -0,0 +1,56 @@
+package esi
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type failingTransport struct{}
+
+func (failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, http.ErrServerClosed
+}
+
+func TestIsAvailable(t *testing.T) {
+	tests := []struct {
+		name string
+		now  time.Time
+		want bool
+	}{
+		{"before the downtime", time.Date(2026, 10, 19, 10, 59, 59, 0, time.UTC), true},
+		{"start of the downtime", time.Date(2026, 10, 19, 11, 0, 0, 0, time.UTC), false},
+		{"during the downtime", time.Date(2026, 10, 19, 11, 29, 59, 0, time.UTC), false},
+		{"after the downtime without status", time.Date(2026, 10, 19, 11, 45, 0, 0, time.UTC), false},
+		{"after the status checks", time.Date(2026, 10, 19, 13, 0, 0, 0, time.UTC), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := tt.now
+			client, err := NewClient(Config{Transport: failingTransport{}, MaxRetries: -1, Clock: func() time.Time { return now }})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := client.IsAvailable(context.Background()); got != tt.want {
+				t.Errorf("got %v at %s, want %v", got, now.Format(time.RFC3339), tt.want)
+			}
+		})
+	}
+}
+
+func TestResumeAt(t *testing.T) {
+	now := time.Date(2026, 10, 19, 11, 10, 0, 0, time.UTC)
+	client, _ := NewClient(Config{Clock: func() time.Time { return now }})
+
+	if got := client.ResumeAt(client.Now()); !got.Equal(time.Date(2026, 10, 19, 11, 30, 0, 0, time.UTC)) {
+		t.Errorf("got %s, want the end of the downtime", got)
+	}
+
+	now = time.Date(2026, 10, 19, 11, 45, 0, 0, time.UTC)
+	if got := client.ResumeAt(client.Now()); !got.Equal(now.Add(unavailableRetryDelay)) {
+		t.Errorf("got %s, want a retry in %s", got, unavailableRetryDelay)
+	}
+}
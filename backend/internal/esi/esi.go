@@ -0,0 +1,275 @@
+package esi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+)
+
+const (
+	DefaultBaseUrl    = "https://esi.evetech.net/latest"
+	DefaultDatasource = "tranquility"
+	DefaultUserAgent  = "wall-eve (+https://github.com/hyoa/wall-eve)"
+	DefaultTimeout    = 30 * time.Second
+	DefaultMaxRetries = 3
+	DefaultRetryDelay = 500 * time.Millisecond
+)
+
+type Config struct {
+	BaseUrl    string
+	Datasource string
+	UserAgent  string
+	Timeout    time.Duration
+	Transport  http.RoundTripper
+	// Mode record every response into CassetteDir, or replay them from it
+	Mode        string
+	CassetteDir string
+	// MaxRetries is the number of retries after a failed attempt, a negative value disable them
+	MaxRetries int
+	RetryDelay time.Duration
+	// Redis enable the shared error-limit governor when set
+	Redis               *goredis.Client
+	ErrorLimitThreshold int
+	// Clock return the current time for the downtime checks, time.Now when nil
+	Clock func() time.Time
+}
+
+type Client struct {
+	baseUrl    string
+	datasource string
+	userAgent  string
+	httpClient *http.Client
+	governor   *governor
+	maxRetries int
+	retryDelay time.Duration
+	status     statusCache
+	clock      func() time.Time
+}
+
+func NewClient(config Config) (*Client, error) {
+	if config.BaseUrl == "" {
+		config.BaseUrl = DefaultBaseUrl
+	}
+
+	if config.Datasource == "" {
+		config.Datasource = DefaultDatasource
+	}
+
+	if config.UserAgent == "" {
+		config.UserAgent = DefaultUserAgent
+	}
+
+	if config.Timeout == 0 {
+		config.Timeout = DefaultTimeout
+	}
+
+	if config.Transport == nil {
+		config.Transport = http.DefaultTransport
+	}
+
+	switch config.Mode {
+	case ModeLive:
+	case ModeRecord:
+		transport, errTransport := newRecordTransport(config.CassetteDir, config.Transport)
+		if errTransport != nil {
+			return nil, fmt.Errorf("unable to record into %s: %w", config.CassetteDir, errTransport)
+		}
+		config.Transport = transport
+	case ModeReplay:
+		transport, errTransport := newReplayTransport(config.CassetteDir)
+		if errTransport != nil {
+			return nil, fmt.Errorf("unable to replay from %s: %w", config.CassetteDir, errTransport)
+		}
+		config.Transport = transport
+	default:
+		return nil, fmt.Errorf("unknown ESI mode %s", config.Mode)
+	}
+
+	if config.MaxRetries == 0 {
+		config.MaxRetries = DefaultMaxRetries
+	} else if config.MaxRetries < 0 {
+		config.MaxRetries = 0
+	}
+
+	if config.RetryDelay == 0 {
+		config.RetryDelay = DefaultRetryDelay
+	}
+
+	if config.Clock == nil {
+		config.Clock = time.Now
+	}
+
+	client := &Client{
+		baseUrl:    strings.TrimRight(config.BaseUrl, "/"),
+		datasource: config.Datasource,
+		userAgent:  config.UserAgent,
+		httpClient: &http.Client{
+			Timeout:   config.Timeout,
+			Transport: config.Transport,
+		},
+		maxRetries: config.MaxRetries,
+		retryDelay: config.RetryDelay,
+		clock:      config.Clock,
+	}
+
+	if config.Redis != nil {
+		client.governor = newGovernor(config.Redis, config.ErrorLimitThreshold)
+	}
+
+	return client, nil
+}
+
+// ConfigFromEnv read the ESI_* variables, any missing value falls back on the defaults.
+func ConfigFromEnv() Config {
+	config := Config{
+		BaseUrl:     os.Getenv("ESI_BASE_URL"),
+		Datasource:  os.Getenv("ESI_DATASOURCE"),
+		UserAgent:   os.Getenv("ESI_USER_AGENT"),
+		Mode:        os.Getenv("ESI_MODE"),
+		CassetteDir: os.Getenv("ESI_CASSETTE_DIR"),
+	}
+
+	if val := os.Getenv("ESI_TIMEOUT"); val != "" {
+		if timeout, err := time.ParseDuration(val); err == nil {
+			config.Timeout = timeout
+		}
+	}
+
+	if val := os.Getenv("ESI_MAX_RETRIES"); val != "" {
+		if maxRetries, err := strconv.Atoi(val); err == nil {
+			config.MaxRetries = maxRetries
+		}
+	}
+
+	if val := os.Getenv("ESI_ERROR_LIMIT_THRESHOLD"); val != "" {
+		if threshold, err := strconv.Atoi(val); err == nil {
+			config.ErrorLimitThreshold = threshold
+		}
+	}
+
+	return config
+}
+
+// ConfigWithFlags return ConfigFromEnv, the mode and the cassette dir given as flags take precedence when they are set.
+func ConfigWithFlags(mode string, cassetteDir string) Config {
+	config := ConfigFromEnv()
+
+	if mode != "" {
+		config.Mode = mode
+	}
+
+	if cassetteDir != "" {
+		config.CassetteDir = cassetteDir
+	}
+
+	return config
+}
+
+func (c *Client) Datasource() string {
+	return c.datasource
+}
+
+// Url build the full url for an ESI path, the datasource is always added to the query.
+func (c *Client) Url(path string, query url.Values) string {
+	q := url.Values{}
+	for k := range query {
+		q[k] = query[k]
+	}
+	q.Set("datasource", c.datasource)
+
+	return fmt.Sprintf("%s/%s/?%s", c.baseUrl, strings.Trim(path, "/"), q.Encode())
+}
+
+// Fetch send a request and hand the response to handle, the whole exchange is retried
+// with backoff when ESI fail (5xx, 420, timeout, truncated body). Client errors (4xx) are returned as is.
+func (c *Client) Fetch(ctx context.Context, method string, path string, query url.Values, handle func(resp *http.Response) error) error {
+	for attempt := 0; ; attempt++ {
+		errAttempt := c.attempt(ctx, method, path, query, handle)
+
+		if errAttempt == nil {
+			return nil
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if !isRetryable(errAttempt) || attempt >= c.maxRetries {
+			return errAttempt
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(c.backoff(attempt)):
+		}
+	}
+}
+
+// GetJSON decode the body of a GET request into v and return the response headers.
+func (c *Client) GetJSON(ctx context.Context, path string, query url.Values, v interface{}) (http.Header, error) {
+	var header http.Header
+
+	errFetch := c.Fetch(ctx, http.MethodGet, path, query, func(resp *http.Response) error {
+		header = resp.Header
+
+		return json.NewDecoder(resp.Body).Decode(v)
+	})
+
+	return header, errFetch
+}
+
+func (c *Client) Head(ctx context.Context, path string, query url.Values) (http.Header, error) {
+	var header http.Header
+
+	errFetch := c.Fetch(ctx, http.MethodHead, path, query, func(resp *http.Response) error {
+		header = resp.Header
+
+		return nil
+	})
+
+	return header, errFetch
+}
+
+func (c *Client) attempt(ctx context.Context, method string, path string, query url.Values, handle func(resp *http.Response) error) error {
+	u := c.Url(path, query)
+	req, errReq := http.NewRequestWithContext(ctx, method, u, nil)
+
+	if errReq != nil {
+		return errReq
+	}
+
+	req.Header.Set("User-Agent", c.userAgent)
+	req.Header.Set("Accept", "application/json")
+
+	if c.governor != nil {
+		if errWait := c.governor.wait(ctx); errWait != nil {
+			return errWait
+		}
+	}
+
+	resp, errDo := c.httpClient.Do(req)
+
+	if errDo != nil {
+		return errDo
+	}
+	defer resp.Body.Close()
+
+	if c.governor != nil {
+		c.governor.record(ctx, resp)
+	}
+
+	if resp.StatusCode >= 400 {
+		return &StatusError{Url: u, StatusCode: resp.StatusCode}
+	}
+
+	return handle(resp)
+}
@@ -0,0 +1,93 @@
+package esi
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultErrorLimitThreshold = 20
+	// Not namespaced: ESI count the errors by client whatever the datasource
+	errorLimitRemainKey = "esi:errorLimitRemain"
+)
+
+// Keep the lowest budget seen during the current window: concurrent responses can
+// come back out of order and a late one must not restore a budget already spent.
+var recordErrorLimitScript = goredis.NewScript(`
+local current = redis.call("GET", KEYS[1])
+if current and tonumber(current) <= tonumber(ARGV[1]) then
+	return current
+end
+redis.call("SET", KEYS[1], ARGV[1], "EX", ARGV[2])
+return ARGV[1]
+`)
+
+// governor share the ESI error budget between every worker through redis,
+// so all of them back off together before ESI ban us.
+type governor struct {
+	client    *goredis.Client
+	threshold int
+}
+
+func newGovernor(client *goredis.Client, threshold int) *governor {
+	if threshold <= 0 {
+		threshold = DefaultErrorLimitThreshold
+	}
+
+	return &governor{
+		client:    client,
+		threshold: threshold,
+	}
+}
+
+// wait block while the remaining budget is under the threshold, until the window reset.
+func (g *governor) wait(ctx context.Context) error {
+	for {
+		remain, errGet := g.client.Get(ctx, errorLimitRemainKey).Int()
+
+		if errGet != nil || remain > g.threshold {
+			return nil
+		}
+
+		ttl, errTtl := g.client.TTL(ctx, errorLimitRemainKey).Result()
+
+		if errTtl != nil || ttl <= 0 {
+			return nil
+		}
+
+		log.Warnf("ESI error budget is low (%d remaining), wait %s before next request", remain, ttl)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(ttl):
+		}
+	}
+}
+
+func (g *governor) record(ctx context.Context, resp *http.Response) {
+	remain, errRemain := strconv.Atoi(resp.Header.Get("X-ESI-Error-Limit-Remain"))
+	reset, errReset := strconv.Atoi(resp.Header.Get("X-ESI-Error-Limit-Reset"))
+
+	if errRemain != nil || errReset != nil {
+		if resp.StatusCode != 420 {
+			return
+		}
+
+		// Error limited without headers, consider the budget spent for a full window
+		remain, reset = 0, 60
+	}
+
+	if reset <= 0 {
+		reset = 1
+	}
+
+	if errRecord := recordErrorLimitScript.Run(ctx, g.client, []string{errorLimitRemainKey}, remain, reset).Err(); errRecord != nil {
+		log.Errorln(errRecord)
+	}
+}
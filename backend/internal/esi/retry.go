@@ -0,0 +1,48 @@
+package esi
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"time"
+)
+
+const maxRetryDelay = 30 * time.Second
+
+type StatusError struct {
+	Url        string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("ESI answered %d for url %s", e.StatusCode, e.Url)
+}
+
+func isRetryable(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		// 420 is the error limit of ESI, the governor make the next attempt wait for the reset
+		return statusErr.StatusCode >= 500 || statusErr.StatusCode == 420
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	// Body cut before its end
+	return errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// backoff is exponential with jitter, capped to maxRetryDelay.
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.retryDelay << attempt
+
+	if delay <= 0 || delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
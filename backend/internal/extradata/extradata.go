@@ -2,13 +2,13 @@ package extradata
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"net/http"
+	"net/url"
 	"sync"
 
 	goredis "github.com/go-redis/redis/v8"
+	"github.com/hyoa/wall-eve/backend/internal/esi"
+	"github.com/hyoa/wall-eve/backend/internal/namespace"
 	"github.com/panjf2000/ants/v2"
 )
 
@@ -16,7 +16,7 @@ type UniverseElementName struct {
 	Name string `json:"name"`
 }
 
-func FetchExtraData(extraData map[string]map[int]string, client *goredis.Client) map[string]map[int]string {
+func FetchExtraData(extraData map[string]map[int]string, client *goredis.Client, esiClient *esi.Client) map[string]map[int]string {
 	pool, _ := ants.NewPoolWithFunc(50, taskFetchExtraDataHandler)
 	defer pool.Release()
 
@@ -28,11 +28,12 @@ func FetchExtraData(extraData map[string]map[int]string, client *goredis.Client)
 			wg.Add(1)
 			var value string
 			task := &taskFetchDataPayload{
-				wg:     &wg,
-				kind:   kind,
-				id:     id,
-				client: client,
-				value:  &value,
+				wg:        &wg,
+				kind:      kind,
+				id:        id,
+				client:    client,
+				esiClient: esiClient,
+				value:     &value,
 			}
 			tasks = append(tasks, task)
 			pool.Invoke(task)
@@ -52,29 +53,52 @@ func FetchExtraData(extraData map[string]map[int]string, client *goredis.Client)
 	return extraData
 }
 
-func GetRegionName(regionId int) (string, error) {
-	return getElementName(regionId, "regions")
-}
+// GetCachedNames read the names already fetched by the indexer, an unknown id has an empty name.
+func GetCachedNames(kind string, ids []int, client *goredis.Client) (map[int]string, error) {
+	names := make(map[int]string)
 
-func getElementName(typeId int, kind string) (string, error) {
-	url := fmt.Sprintf("https://esi.evetech.net/latest/universe/%s/%d/?datasource=tranquility&language=en", kind, typeId)
-	resp, errGet := http.Get(url)
+	if len(ids) == 0 {
+		return names, nil
+	}
 
-	if errGet != nil {
-		return "", fmt.Errorf("Unable to fetch for url %s: %w", url, errGet)
+	keys := make([]string, 0)
+	for _, id := range ids {
+		keys = append(keys, namespace.Key(kind, id))
 	}
 
-	b, errBody := ioutil.ReadAll(resp.Body)
+	values, err := client.MGet(context.Background(), keys...).Result()
 
-	if errBody != nil {
-		return "", fmt.Errorf("Unable to fetch for url %s: %w", url, errBody)
+	if err != nil {
+		return names, err
 	}
 
+	for k, id := range ids {
+		if val, ok := values[k].(string); ok {
+			names[id] = val
+		}
+	}
+
+	return names, nil
+}
+
+func GetRegionName(regionId int, esiClient *esi.Client) (string, error) {
+	return getElementName(regionId, "regions", esiClient)
+}
+
+func getElementName(typeId int, kind string, esiClient *esi.Client) (string, error) {
+	path := fmt.Sprintf("universe/%s/%d", kind, typeId)
+	query := url.Values{"language": []string{"en"}}
+	u := esiClient.Url(path, query)
+
 	var item UniverseElementName
-	json.Unmarshal(b, &item)
+	_, errGet := esiClient.GetJSON(context.Background(), path, query, &item)
+
+	if errGet != nil {
+		return "", fmt.Errorf("Unable to fetch for url %s: %w", u, errGet)
+	}
 
 	if item.Name == "" {
-		return "", fmt.Errorf("No name for url %s", url)
+		return "", fmt.Errorf("No name for url %s", u)
 	}
 
 	return item.Name, nil
@@ -86,20 +110,21 @@ func taskFetchExtraDataHandler(data interface{}) {
 }
 
 type taskFetchDataPayload struct {
-	wg     *sync.WaitGroup
-	kind   string
-	id     int
-	value  *string
-	client *goredis.Client
+	wg        *sync.WaitGroup
+	kind      string
+	id        int
+	value     *string
+	client    *goredis.Client
+	esiClient *esi.Client
 }
 
 func (t *taskFetchDataPayload) fetch() {
-	val, errGet := t.client.Get(context.Background(), fmt.Sprintf("%s:%d", t.kind, t.id)).Result()
+	val, errGet := t.client.Get(context.Background(), namespace.Key(t.kind, t.id)).Result()
 
 	if errGet != nil || val == "" {
-		val, _ = getElementName(t.id, t.kind)
+		val, _ = getElementName(t.id, t.kind, t.esiClient)
 
-		t.client.Set(context.Background(), fmt.Sprintf("%s:%d", t.kind, t.id), val, 0)
+		t.client.Set(context.Background(), namespace.Key(t.kind, t.id), val, 0)
 	}
 
 	t.value = &val
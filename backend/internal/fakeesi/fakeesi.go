@@ -0,0 +1,395 @@
+package fakeesi
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	errorLimit       = 100
+	errorLimitWindow = time.Minute
+	// The order ids are built from the region, the page and the position in the page, they must not overlap
+	MaxOrdersPerPage  = 100000
+	MaxPagesPerRegion = 999
+)
+
+var DefaultRegions = []int{10000002, 10000032}
+
+var knownRegionNames = map[int]string{
+	10000002: "The Forge",
+	10000030: "Heimatar",
+	10000032: "Sinq Laison",
+	10000042: "Metropolis",
+	10000043: "Domain",
+}
+
+var knownTypes = []struct {
+	id    int
+	name  string
+	price float64
+}{
+	{34, "Tritanium", 5},
+	{35, "Pyerite", 10},
+	{36, "Mexallon", 70},
+	{37, "Isogen", 150},
+	{38, "Nocxium", 800},
+	{39, "Zydrine", 1200},
+	{40, "Megacyte", 2500},
+	{587, "Rifter", 500000},
+	{1137, "Antimatter Charge S", 40},
+	{2046, "Damage Control I", 30000},
+	{11399, "Morphite", 9000},
+	{43694, "'Augmented' Mining Drone", 20000000},
+	{44992, "PLEX", 4500000},
+}
+
+type Config struct {
+	// Seed make the universe and the orders deterministic
+	Seed              int64
+	Regions           []int
+	SystemsPerRegion  int
+	StationsPerSystem int
+	PagesPerRegion    int
+	OrdersPerPage     int
+	// Latency is added to every response
+	Latency time.Duration
+	// Probability, between 0 and 1, to answer a 5xx, a 420 or a truncated body
+	ErrorRate      float64
+	ErrorLimitRate float64
+	TruncateRate   float64
+	// Clock return the current time of the server, time.Now when nil
+	Clock func() time.Time
+}
+
+// Server serve a synthetic ESI: market orders, universe names and status.
+type Server struct {
+	config       Config
+	names        map[string]map[int]string
+	stations     map[int][]int
+	systems      map[int]int
+	lastModified time.Time
+
+	mu          sync.Mutex
+	attempts    map[string]int
+	errorRemain int
+	errorReset  time.Time
+}
+
+func New(config Config) (*Server, error) {
+	if len(config.Regions) == 0 {
+		config.Regions = DefaultRegions
+	}
+
+	if config.SystemsPerRegion == 0 {
+		config.SystemsPerRegion = 5
+	}
+
+	if config.StationsPerSystem == 0 {
+		config.StationsPerSystem = 3
+	}
+
+	if config.PagesPerRegion == 0 {
+		config.PagesPerRegion = 3
+	}
+
+	if config.OrdersPerPage == 0 {
+		config.OrdersPerPage = 1000
+	}
+
+	if config.OrdersPerPage < 0 || config.OrdersPerPage >= MaxOrdersPerPage {
+		return nil, fmt.Errorf("orders per page must be between 1 and %d", MaxOrdersPerPage-1)
+	}
+
+	if config.PagesPerRegion < 0 || config.PagesPerRegion > MaxPagesPerRegion {
+		return nil, fmt.Errorf("pages per region must be between 1 and %d", MaxPagesPerRegion)
+	}
+
+	if config.Clock == nil {
+		config.Clock = time.Now
+	}
+
+	s := &Server{
+		config: config,
+		names: map[string]map[int]string{
+			"regions":  make(map[int]string),
+			"systems":  make(map[int]string),
+			"stations": make(map[int]string),
+			"types":    make(map[int]string),
+		},
+		stations:     make(map[int][]int),
+		systems:      make(map[int]int),
+		lastModified: config.Clock().UTC().Truncate(5 * time.Minute),
+		attempts:     make(map[string]int),
+		errorRemain:  errorLimit,
+		errorReset:   config.Clock().Add(errorLimitWindow),
+	}
+
+	s.buildUniverse()
+
+	return s, nil
+}
+
+// buildUniverse derive every id from the position of the region, so the same configuration always give the same universe.
+func (s *Server) buildUniverse() {
+	for ri, regionId := range s.config.Regions {
+		regionName, ok := knownRegionNames[regionId]
+		if !ok {
+			regionName = fmt.Sprintf("Region %d", regionId)
+		}
+		s.names["regions"][regionId] = regionName
+
+		for si := 0; si < s.config.SystemsPerRegion; si++ {
+			systemId := 30000000 + ri*100 + si
+			systemName := fmt.Sprintf("%s %s", regionName, romanNumber(si+1))
+			s.names["systems"][systemId] = systemName
+
+			for sti := 0; sti < s.config.StationsPerSystem; sti++ {
+				stationId := 60000000 + ri*10000 + si*100 + sti
+				s.names["stations"][stationId] = fmt.Sprintf("%s - Moon %d - Trade Hub", systemName, sti+1)
+				s.stations[regionId] = append(s.stations[regionId], stationId)
+				s.systems[stationId] = systemId
+			}
+		}
+	}
+
+	for _, t := range knownTypes {
+		s.names["types"][t.id] = t.name
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.config.Latency > 0 {
+		time.Sleep(s.config.Latency)
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) > 0 && (parts[0] == "latest" || parts[0] == "dev" || strings.HasPrefix(parts[0], "v")) {
+		parts = parts[1:]
+	}
+
+	faults := s.faultsFor(r)
+
+	if status, limited := s.injectFault(faults); limited {
+		s.writeError(w, status, "Injected fault")
+		return
+	}
+
+	truncate := faults.Float64() < s.config.TruncateRate
+
+	switch {
+	case len(parts) == 1 && parts[0] == "status":
+		s.writeJSON(w, map[string]interface{}{
+			"players":        23000,
+			"server_version": "2000000",
+			"start_time":     s.config.Clock().UTC().Truncate(24 * time.Hour).Add(11 * time.Hour).Format(time.RFC3339),
+		}, truncate)
+	case len(parts) == 3 && parts[0] == "markets" && parts[2] == "orders":
+		s.serveOrders(w, r, parts[1], truncate)
+	case len(parts) == 3 && parts[0] == "universe":
+		s.serveName(w, parts[1], parts[2], truncate)
+	default:
+		s.writeError(w, http.StatusNotFound, "Not found")
+	}
+}
+
+func (s *Server) serveOrders(w http.ResponseWriter, r *http.Request, region string, truncate bool) {
+	regionId, _ := strconv.Atoi(region)
+
+	if _, ok := s.stations[regionId]; !ok {
+		s.writeError(w, http.StatusNotFound, "Region not found!")
+		return
+	}
+
+	page := 1
+	if val := r.URL.Query().Get("page"); val != "" {
+		page, _ = strconv.Atoi(val)
+	}
+
+	if page < 1 || page > s.config.PagesPerRegion {
+		s.writeError(w, http.StatusNotFound, "Requested page does not exist!")
+		return
+	}
+
+	w.Header().Set("X-Pages", strconv.Itoa(s.config.PagesPerRegion))
+	s.writeJSON(w, s.Orders(regionId, page), truncate)
+}
+
+func (s *Server) serveName(w http.ResponseWriter, kind string, id string, truncate bool) {
+	elementId, _ := strconv.Atoi(id)
+	names, ok := s.names[kind]
+
+	if !ok {
+		s.writeError(w, http.StatusNotFound, "Not found")
+		return
+	}
+
+	name, ok := names[elementId]
+	if !ok {
+		s.writeError(w, http.StatusNotFound, fmt.Sprintf("%s not found", strings.TrimSuffix(kind, "s")))
+		return
+	}
+
+	s.writeJSON(w, map[string]interface{}{"name": name}, truncate)
+}
+
+// Order has the same fields than an ESI market order.
+type Order struct {
+	Duration     int     `json:"duration"`
+	IsBuyOrder   bool    `json:"is_buy_order"`
+	Issued       string  `json:"issued"`
+	LocationId   int     `json:"location_id"`
+	MinVolume    int     `json:"min_volume"`
+	OrderId      int     `json:"order_id"`
+	Price        float64 `json:"price"`
+	Range        string  `json:"range"`
+	SystemId     int     `json:"system_id"`
+	TypeId       int     `json:"type_id"`
+	VolumeRemain int     `json:"volume_remain"`
+	VolumeTotal  int     `json:"volume_total"`
+}
+
+// Orders generate a page of orders, the same region and page always give the same orders for a seed.
+func (s *Server) Orders(regionId int, page int) []Order {
+	rnd := rand.New(rand.NewSource(s.config.Seed ^ int64(regionId)<<16 ^ int64(page)))
+	stations := s.stations[regionId]
+	orders := make([]Order, 0, s.config.OrdersPerPage)
+
+	for i := 0; i < s.config.OrdersPerPage; i++ {
+		t := knownTypes[rnd.Intn(len(knownTypes))]
+		stationId := stations[rnd.Intn(len(stations))]
+		isBuyOrder := rnd.Intn(2) == 0
+		price := t.price * (1.05 + rnd.Float64()*0.2)
+		if isBuyOrder {
+			price = t.price * (0.75 + rnd.Float64()*0.2)
+		}
+		volume := 1 + rnd.Intn(1000)
+
+		orders = append(orders, Order{
+			Duration:     90,
+			IsBuyOrder:   isBuyOrder,
+			Issued:       time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(rnd.Intn(86400*30)) * time.Second).Format(time.RFC3339),
+			LocationId:   stationId,
+			MinVolume:    1,
+			OrderId:      regionId%1000*100000000 + page*100000 + i,
+			Price:        float64(int(price*100)) / 100,
+			Range:        "region",
+			SystemId:     s.systems[stationId],
+			TypeId:       t.id,
+			VolumeRemain: volume,
+			VolumeTotal:  volume,
+		})
+	}
+
+	return orders
+}
+
+// faultsFor return the random source of the faults of a request. It depend only of the seed, the url and the number
+// of times it has been requested, eg: the second attempt of page 2 of a region, whatever the order of the requests.
+func (s *Server) faultsFor(r *http.Request) *rand.Rand {
+	key := r.URL.Path + "?page=" + r.URL.Query().Get("page")
+
+	s.mu.Lock()
+	attempt := s.attempts[key]
+	s.attempts[key]++
+	s.mu.Unlock()
+
+	h := fnv.New64a()
+	h.Write([]byte(key))
+
+	return rand.New(rand.NewSource(s.config.Seed ^ int64(h.Sum64()) ^ int64(attempt)<<48))
+}
+
+// injectFault roll the configured probabilities, an exhausted error budget always answer 420 like ESI.
+func (s *Server) injectFault(faults *rand.Rand) (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.errorRemain <= 0 && s.config.Clock().Before(s.errorReset) {
+		return 420, true
+	}
+
+	if faults.Float64() < s.config.ErrorRate {
+		return []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}[faults.Intn(4)], true
+	}
+
+	if faults.Float64() < s.config.ErrorLimitRate {
+		return 420, true
+	}
+
+	return 0, false
+}
+
+// spendError decrement the error budget of the current window and return the headers values.
+func (s *Server) spendError(statusCode int) (int, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.config.Clock()
+	if now.After(s.errorReset) {
+		s.errorRemain = errorLimit
+		s.errorReset = now.Add(errorLimitWindow)
+	}
+
+	if statusCode >= 400 && s.errorRemain > 0 {
+		s.errorRemain--
+	}
+
+	return s.errorRemain, int(s.errorReset.Sub(now).Seconds()) + 1
+}
+
+func (s *Server) writeHeaders(w http.ResponseWriter, statusCode int) {
+	remain, reset := s.spendError(statusCode)
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.Header().Set("X-ESI-Error-Limit-Remain", strconv.Itoa(remain))
+	w.Header().Set("X-ESI-Error-Limit-Reset", strconv.Itoa(reset))
+	w.Header().Set("Last-Modified", s.lastModified.Format(http.TimeFormat))
+	w.Header().Set("Expires", s.lastModified.Add(5*time.Minute).Format(http.TimeFormat))
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, v interface{}, truncate bool) {
+	b, _ := json.Marshal(v)
+	s.writeHeaders(w, http.StatusOK)
+
+	// Announce the full body but send only half of it, the client see an unexpected EOF
+	if truncate {
+		w.Header().Set("Content-Length", strconv.Itoa(len(b)))
+		w.WriteHeader(http.StatusOK)
+		w.Write(b[:len(b)/2])
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+func (s *Server) writeError(w http.ResponseWriter, statusCode int, message string) {
+	b, _ := json.Marshal(map[string]string{"error": message})
+	s.writeHeaders(w, statusCode)
+	w.WriteHeader(statusCode)
+	w.Write(b)
+}
+
+func romanNumber(n int) string {
+	numerals := []struct {
+		value  int
+		symbol string
+	}{{10, "X"}, {9, "IX"}, {5, "V"}, {4, "IV"}, {1, "I"}}
+
+	res := ""
+	for _, numeral := range numerals {
+		for n >= numeral.value {
+			res += numeral.symbol
+			n -= numeral.value
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,144 @@
+package fakeesi_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hyoa/wall-eve/backend/internal/esi"
+	"github.com/hyoa/wall-eve/backend/internal/extradata"
+	"github.com/hyoa/wall-eve/backend/internal/fakeesi"
+	"github.com/hyoa/wall-eve/backend/internal/order"
+)
+
+var afterDowntime = time.Date(2026, 10, 19, 11, 45, 0, 0, time.UTC)
+
+func newFake(t *testing.T, config fakeesi.Config) (*fakeesi.Server, *esi.Client) {
+	t.Helper()
+
+	if config.Clock == nil {
+		config.Clock = func() time.Time { return afterDowntime }
+	}
+
+	fake, err := fakeesi.New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	client, err := esi.NewClient(esi.Config{
+		BaseUrl:    server.URL + "/latest",
+		MaxRetries: 10,
+		RetryDelay: time.Millisecond,
+		Clock:      config.Clock,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return fake, client
+}
+
+// The indexer fetch and fold the orders of a region, the faults are retried until the result is the generated one.
+func TestAggregateOrdersWithFaults(t *testing.T) {
+	config := fakeesi.Config{Seed: 42, PagesPerRegion: 4, OrdersPerPage: 500, ErrorRate: 0.2, TruncateRate: 0.1}
+	fake, client := newFake(t, config)
+
+	aggregates, err := order.AggregateOrdersFromEsiForRegion(context.Background(), 10000002, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := order.NewAggregates()
+	seen := make(map[int]bool)
+	for page := 1; page <= config.PagesPerRegion; page++ {
+		content, _ := json.Marshal(fake.Orders(10000002, page))
+
+		var orders []order.Order
+		if errDecode := json.Unmarshal(content, &orders); errDecode != nil {
+			t.Fatal(errDecode)
+		}
+
+		for _, o := range orders {
+			if seen[o.OrderId] {
+				t.Fatalf("order id %d is generated twice", o.OrderId)
+			}
+			seen[o.OrderId] = true
+			expected.Add(o)
+		}
+	}
+
+	if !reflect.DeepEqual(aggregates.Items(), expected.Items()) {
+		t.Errorf("got %d aggregates, want the %d generated ones", len(aggregates.Items()), len(expected.Items()))
+	}
+
+	if lastModified := aggregates.LastModified(); !lastModified.Equal(afterDowntime.Truncate(5 * time.Minute)) {
+		t.Errorf("got last modified %s, want the one of the clock", lastModified)
+	}
+}
+
+// The scheduler validate a region with its name, an unknown region is a client error.
+func TestRegionNames(t *testing.T) {
+	_, client := newFake(t, fakeesi.Config{Seed: 1})
+
+	name, err := extradata.GetRegionName(10000002, client)
+	if err != nil || name != "The Forge" {
+		t.Errorf("got %q, %v, want The Forge", name, err)
+	}
+
+	_, err = extradata.GetRegionName(10000099, client)
+
+	var statusErr *esi.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Errorf("got %v, want a 404", err)
+	}
+}
+
+// The workers check /status/ after the downtime window.
+func TestAvailableAfterDowntime(t *testing.T) {
+	_, client := newFake(t, fakeesi.Config{Seed: 1})
+
+	if !client.IsAvailable(context.Background()) {
+		t.Error("got unavailable, want the fake status to be up")
+	}
+}
+
+// The faults of a page depend on its attempts only, not on the order of the requests.
+func TestFaultsDoNotDependOnArrivalOrder(t *testing.T) {
+	config := fakeesi.Config{Seed: 7, ErrorRate: 0.5}
+	statuses := func(pages []string) map[string][]int {
+		fake, err := fakeesi.New(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		result := make(map[string][]int)
+		for _, page := range pages {
+			recorder := httptest.NewRecorder()
+			fake.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/latest/markets/10000002/orders/?page="+page, nil))
+			result[page] = append(result[page], recorder.Code)
+		}
+
+		return result
+	}
+
+	inOrder := statuses([]string{"1", "1", "2", "2", "3", "3"})
+	shuffled := statuses([]string{"3", "2", "1", "3", "1", "2"})
+
+	if !reflect.DeepEqual(inOrder, shuffled) {
+		t.Errorf("got %v then %v, want the same faults", inOrder, shuffled)
+	}
+}
+
+func TestOrdersPerPageLimit(t *testing.T) {
+	if _, err := fakeesi.New(fakeesi.Config{OrdersPerPage: fakeesi.MaxOrdersPerPage}); err == nil {
+		t.Errorf("got no error for %d orders per page, the order ids would overlap", fakeesi.MaxOrdersPerPage)
+	}
+}
@@ -0,0 +1,184 @@
+package graph
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+const (
+	maxDepth = 10
+	// maxSearches is the number of FT.SEARCH a query can run, each page of entries is one search
+	maxSearches = 50
+	// estimatedListSize is used for the lists without a limit, eg: the systems of a region
+	estimatedListSize = 100
+)
+
+// complexity estimate the cost of a query before it is executed. A page of entries nested in a list
+// run one search by item of the list, eg: region { systems { locations { entries } } }.
+type complexity struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+	searches  int
+}
+
+// checkComplexity refuse the queries that are too deep or that would run too many searches. A query
+// that can not be parsed is accepted, graphql.Do report the error.
+func checkComplexity(schema graphql.Schema, request Request) error {
+	document, errParse := parser.Parse(parser.ParseParams{Source: request.Query})
+
+	if errParse != nil {
+		return nil
+	}
+
+	c := complexity{fragments: make(map[string]*ast.FragmentDefinition), variables: request.Variables}
+	for _, definition := range document.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok && fragment.Name != nil {
+			c.fragments[fragment.Name.Value] = fragment
+		}
+	}
+
+	for _, definition := range document.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+
+		if !ok || operation.Operation != ast.OperationTypeQuery {
+			continue
+		}
+
+		if request.OperationName != "" && (operation.Name == nil || operation.Name.Value != request.OperationName) {
+			continue
+		}
+
+		if err := c.walk(operation.SelectionSet, schema.QueryType(), 1, 0, 0, map[string]bool{}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// walk the selections of the parent type, multiplier is the number of times they are resolved.
+func (c *complexity) walk(set *ast.SelectionSet, parent *graphql.Object, multiplier int, pageLimit int, depth int, spreads map[string]bool) error {
+	if set == nil || parent == nil {
+		return nil
+	}
+
+	for _, selection := range set.Selections {
+		switch s := selection.(type) {
+		case *ast.InlineFragment:
+			if err := c.walk(s.SelectionSet, parent, multiplier, pageLimit, depth, spreads); err != nil {
+				return err
+			}
+		case *ast.FragmentSpread:
+			fragment, ok := c.fragments[s.Name.Value]
+
+			// A fragment that spread itself is refused by the validation
+			if !ok || spreads[s.Name.Value] {
+				continue
+			}
+
+			spreads[s.Name.Value] = true
+			errWalk := c.walk(fragment.SelectionSet, parent, multiplier, pageLimit, depth, spreads)
+			delete(spreads, s.Name.Value)
+
+			if errWalk != nil {
+				return errWalk
+			}
+		case *ast.Field:
+			if err := c.field(s, parent, multiplier, pageLimit, depth+1, spreads); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *complexity) field(field *ast.Field, parent *graphql.Object, multiplier int, pageLimit int, depth int, spreads map[string]bool) error {
+	if depth > maxDepth {
+		return fmt.Errorf("query is too deep, at most %d levels are allowed", maxDepth)
+	}
+
+	definition, ok := parent.Fields()[field.Name.Value]
+
+	if !ok {
+		return nil
+	}
+
+	object, isList := unwrap(definition.Type)
+
+	if isList {
+		size := estimatedListSize
+		if parent.Name() == "MarketPage" {
+			size = pageLimit
+		}
+		multiplier = capped(multiplier * size)
+	}
+
+	if object != nil && object.Name() == "MarketPage" {
+		c.searches = capped(c.searches + multiplier)
+		pageLimit = c.limit(field)
+
+		if c.searches > maxSearches {
+			return fmt.Errorf("query would run %d searches or more, at most %d are allowed", c.searches, maxSearches)
+		}
+	}
+
+	return c.walk(field.SelectionSet, object, multiplier, pageLimit, depth, spreads)
+}
+
+// limit return the limit argument of a page, from the query or its variables.
+func (c *complexity) limit(field *ast.Field) int {
+	for _, argument := range field.Arguments {
+		if argument.Name == nil || argument.Name.Value != "limit" {
+			continue
+		}
+
+		switch value := argument.Value.(type) {
+		case *ast.IntValue:
+			if limit, err := strconv.Atoi(value.Value); err == nil {
+				return limit
+			}
+		case *ast.Variable:
+			switch limit := c.variables[value.Name.Value].(type) {
+			case float64:
+				return int(limit)
+			case int:
+				return limit
+			}
+		}
+	}
+
+	return defaultLimit
+}
+
+// unwrap return the object type of a field, or nil for a scalar, and whether it is a list.
+func unwrap(output graphql.Output) (*graphql.Object, bool) {
+	isList := false
+
+	for {
+		switch t := output.(type) {
+		case *graphql.NonNull:
+			output = t.OfType
+		case *graphql.List:
+			isList = true
+			output = t.OfType
+		case *graphql.Object:
+			return t, isList
+		default:
+			return nil, isList
+		}
+	}
+}
+
+// capped keep the estimations from overflowing, anything above the maximum is refused anyway.
+func capped(value int) int {
+	if value < 0 || value > maxSearches*maxLimit {
+		return maxSearches * maxLimit
+	}
+
+	return value
+}
@@ -0,0 +1,44 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckComplexity(t *testing.T) {
+	schema, err := NewSchema(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		request   Request
+		wantError string
+	}{
+		{"one page", Request{Query: `{ market(location: "jita", limit: 10000) { total entries { buyPrice itemType { name } } } }`}, ""},
+		{"pages side by side", Request{Query: `{ a: market(location: "jita") { total } b: market(location: "amarr") { total } }`}, ""},
+		{"page by system", Request{Query: `{ region(id: 10000002) { systems { entries { total } } } }`}, "searches"},
+		{"page by location of each system", Request{Query: `{ region(id: 10000002) { systems { locations { entries(limit: 10000) { total } } } } }`}, "searches"},
+		{"page by entry", Request{Query: `{ market(location: "jita", limit: 100) { entries { region { entries { total } } } } }`}, "searches"},
+		{"small nested page", Request{Query: `{ market(location: "jita", limit: 10) { entries { region { entries(limit: 1) { total } } } } }`}, ""},
+		{"limit from variables", Request{Query: `query($limit: Int) { market(location: "jita", limit: $limit) { entries { region { entries { total } } } } }`, Variables: map[string]interface{}{"limit": float64(1000)}}, "searches"},
+		{"fragments", Request{Query: `{ region(id: 1) { ...systems } } fragment systems on Region { systems { ... on System { entries { total } } } }`}, "searches"},
+		{"too deep", Request{Query: `{ system(id: 1) { region { systems { region { systems { region { systems { region { systems { region { name } } } } } } } } } } }`}, "too deep"},
+		{"invalid query", Request{Query: `{ market(`}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkComplexity(schema.schema, tt.request)
+
+			if tt.wantError == "" && err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			if tt.wantError != "" && (err == nil || !strings.Contains(err.Error(), tt.wantError)) {
+				t.Errorf("got %v, want an error about %s", err, tt.wantError)
+			}
+		})
+	}
+}
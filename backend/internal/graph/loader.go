@@ -0,0 +1,187 @@
+package graph
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/hyoa/wall-eve/backend/internal/denormorder"
+	"github.com/hyoa/wall-eve/backend/internal/extradata"
+	"github.com/hyoa/wall-eve/backend/internal/universe"
+)
+
+type loaderKey struct{}
+
+// loader cache the universe lookups of a query, so the nested objects of many entries
+// read redis once per region or system instead of once per entry.
+type loader struct {
+	client *goredis.Client
+
+	mu        sync.Mutex
+	regions   []universe.Region
+	systems   map[int][]universe.System
+	locations map[int][]universe.Location
+	types     map[int]string
+}
+
+func newLoader(client *goredis.Client) *loader {
+	return &loader{
+		client:    client,
+		systems:   make(map[int][]universe.System),
+		locations: make(map[int][]universe.Location),
+		types:     make(map[int]string),
+	}
+}
+
+func loaderFromContext(ctx context.Context) *loader {
+	return ctx.Value(loaderKey{}).(*loader)
+}
+
+func (l *loader) getRegions() ([]universe.Region, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.regions != nil {
+		return l.regions, nil
+	}
+
+	regions, err := universe.GetRegions(l.client)
+
+	if err != nil {
+		return nil, err
+	}
+
+	l.regions = regions
+
+	return regions, nil
+}
+
+func (l *loader) getRegion(regionId int) (universe.Region, error) {
+	regions, err := l.getRegions()
+
+	if err != nil {
+		return universe.Region{}, err
+	}
+
+	for _, region := range regions {
+		if region.RegionId == regionId {
+			return region, nil
+		}
+	}
+
+	return universe.Region{}, universe.ErrNotFound
+}
+
+func (l *loader) getSystemsInRegion(regionId int) ([]universe.System, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if systems, ok := l.systems[regionId]; ok {
+		return systems, nil
+	}
+
+	systems, err := universe.GetSystemsInRegion(regionId, l.client)
+
+	if err != nil {
+		return nil, err
+	}
+
+	l.systems[regionId] = systems
+
+	return systems, nil
+}
+
+func (l *loader) getLocationsInSystem(systemId int) ([]universe.Location, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if locations, ok := l.locations[systemId]; ok {
+		return locations, nil
+	}
+
+	locations, err := universe.GetLocationsInSystem(systemId, l.client)
+
+	if err != nil {
+		return nil, err
+	}
+
+	l.locations[systemId] = locations
+
+	return locations, nil
+}
+
+// getSystem go through the locations of the system as they know its region.
+func (l *loader) getSystem(systemId int) (universe.System, error) {
+	locations, err := l.getLocationsInSystem(systemId)
+
+	if err != nil {
+		return universe.System{}, err
+	}
+
+	systems, err := l.getSystemsInRegion(locations[0].RegionId)
+
+	if err != nil {
+		return universe.System{}, err
+	}
+
+	for _, system := range systems {
+		if system.SystemId == systemId {
+			return system, nil
+		}
+	}
+
+	return universe.System{}, universe.ErrNotFound
+}
+
+// getLocation read an entry of the location to know its system.
+func (l *loader) getLocation(locationId int) (universe.Location, error) {
+	filter := denormorder.NewFilter(strconv.Itoa(locationId))
+	filter.Limit = 1
+	result, err := denormorder.GetDenormalizedOrdersWithFilter(filter, l.client)
+
+	if err != nil {
+		return universe.Location{}, err
+	}
+
+	if len(result.Orders) == 0 {
+		return universe.Location{}, universe.ErrNotFound
+	}
+
+	return l.getLocationInSystem(locationId, result.Orders[0].SystemId)
+}
+
+func (l *loader) getLocationInSystem(locationId int, systemId int) (universe.Location, error) {
+	locations, err := l.getLocationsInSystem(systemId)
+
+	if err != nil {
+		return universe.Location{}, err
+	}
+
+	for _, location := range locations {
+		if location.LocationId == locationId {
+			return location, nil
+		}
+	}
+
+	return universe.Location{}, universe.ErrNotFound
+}
+
+func (l *loader) getTypeName(typeId int) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if name, ok := l.types[typeId]; ok {
+		return name, nil
+	}
+
+	names, err := extradata.GetCachedNames("types", []int{typeId}, l.client)
+
+	if err != nil {
+		return "", err
+	}
+
+	l.types[typeId] = names[typeId]
+
+	return names[typeId], nil
+}
@@ -0,0 +1,459 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/hyoa/wall-eve/backend/internal/denormorder"
+	"github.com/hyoa/wall-eve/backend/internal/namespace"
+	"github.com/hyoa/wall-eve/backend/internal/universe"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 10000
+)
+
+type itemType struct {
+	Id   int
+	Name string
+}
+
+type marketPage struct {
+	Total   int
+	Limit   int
+	Offset  int
+	Entries []denormorder.DenormalizedOrder
+}
+
+type Request struct {
+	Query         string                 `json:"query" form:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName" form:"operationName"`
+}
+
+// Schema answer the GraphQL queries over the denormalized orders and the universe of the indexed regions.
+type Schema struct {
+	schema graphql.Schema
+	client *goredis.Client
+}
+
+func NewSchema(client *goredis.Client) (Schema, error) {
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{Query: newQueryType(client)})
+
+	if err != nil {
+		return Schema{}, err
+	}
+
+	return Schema{schema: schema, client: client}, nil
+}
+
+// Do execute a request, the universe lookups are cached for the request only.
+func (s Schema) Do(ctx context.Context, request Request) *graphql.Result {
+	if err := checkComplexity(s.schema, request); err != nil {
+		return &graphql.Result{Errors: []gqlerrors.FormattedError{gqlerrors.FormatError(err)}}
+	}
+
+	return graphql.Do(graphql.Params{
+		Schema:         s.schema,
+		RequestString:  request.Query,
+		VariableValues: request.Variables,
+		OperationName:  request.OperationName,
+		Context:        context.WithValue(ctx, loaderKey{}, newLoader(s.client)),
+	})
+}
+
+func newQueryType(client *goredis.Client) *graphql.Object {
+	sortFieldValues := graphql.EnumValueConfigMap{}
+	for _, field := range denormorder.SortableFields {
+		sortFieldValues[field] = &graphql.EnumValueConfig{Value: field}
+	}
+
+	sortFieldEnum := graphql.NewEnum(graphql.EnumConfig{Name: "SortField", Values: sortFieldValues})
+	sortOrderEnum := graphql.NewEnum(graphql.EnumConfig{
+		Name: "SortOrder",
+		Values: graphql.EnumValueConfigMap{
+			"ASC":  &graphql.EnumValueConfig{Value: "asc"},
+			"DESC": &graphql.EnumValueConfig{Value: "desc"},
+		},
+	})
+	typeNameMatchEnum := graphql.NewEnum(graphql.EnumConfig{
+		Name: "TypeNameMatch",
+		Values: graphql.EnumValueConfigMap{
+			"TEXT":   &graphql.EnumValueConfig{Value: denormorder.TypeNameMatchText},
+			"PREFIX": &graphql.EnumValueConfig{Value: denormorder.TypeNameMatchPrefix},
+			"FUZZY":  &graphql.EnumValueConfig{Value: denormorder.TypeNameMatchFuzzy},
+		},
+	})
+
+	// entriesArgs are the filters of every list of entries, the location is added where it is not implied
+	entriesArgs := func(withLocation bool) graphql.FieldConfigArgument {
+		args := graphql.FieldConfigArgument{
+			"typeName":      &graphql.ArgumentConfig{Type: graphql.String},
+			"typeNameMatch": &graphql.ArgumentConfig{Type: typeNameMatchEnum, DefaultValue: denormorder.TypeNameMatchText},
+			"typeIds":       &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.Int))},
+			"minBuyPrice":   &graphql.ArgumentConfig{Type: graphql.Float},
+			"maxBuyPrice":   &graphql.ArgumentConfig{Type: graphql.Float},
+			"minSellPrice":  &graphql.ArgumentConfig{Type: graphql.Float},
+			"maxSellPrice":  &graphql.ArgumentConfig{Type: graphql.Float},
+			"minBuyVolume":  &graphql.ArgumentConfig{Type: graphql.Int},
+			"maxBuyVolume":  &graphql.ArgumentConfig{Type: graphql.Int},
+			"minSellVolume": &graphql.ArgumentConfig{Type: graphql.Int},
+			"maxSellVolume": &graphql.ArgumentConfig{Type: graphql.Int},
+			"sortBy":        &graphql.ArgumentConfig{Type: sortFieldEnum},
+			"order":         &graphql.ArgumentConfig{Type: sortOrderEnum, DefaultValue: "asc"},
+			"limit":         &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultLimit},
+			"offset":        &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 0},
+		}
+
+		if withLocation {
+			args["location"] = &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String), Description: "Name or id of a region, a system or a location"}
+		}
+
+		return args
+	}
+
+	// The entries of an item type are only the ones of its type
+	itemTypeEntriesArgs := entriesArgs(true)
+	delete(itemTypeEntriesArgs, "typeIds")
+
+	regionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Region",
+		Fields: graphql.Fields{
+			"id":         &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: regionField(func(v universe.Region) interface{} { return v.RegionId })},
+			"name":       &graphql.Field{Type: graphql.String, Resolve: regionField(func(v universe.Region) interface{} { return v.RegionName })},
+			"entryCount": &graphql.Field{Type: graphql.Int, Resolve: regionField(func(v universe.Region) interface{} { return v.DocumentCount })},
+			"indexedAt":  &graphql.Field{Type: graphql.DateTime, Resolve: regionField(func(v universe.Region) interface{} { return v.IndexedAt })},
+		},
+	})
+
+	systemType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "System",
+		Fields: graphql.Fields{
+			"id":         &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: systemField(func(v universe.System) interface{} { return v.SystemId })},
+			"name":       &graphql.Field{Type: graphql.String, Resolve: systemField(func(v universe.System) interface{} { return v.SystemName })},
+			"entryCount": &graphql.Field{Type: graphql.Int, Resolve: systemField(func(v universe.System) interface{} { return v.DocumentCount })},
+			"indexedAt":  &graphql.Field{Type: graphql.DateTime, Resolve: systemField(func(v universe.System) interface{} { return v.IndexedAt })},
+		},
+	})
+
+	locationType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Location",
+		Fields: graphql.Fields{
+			"id":         &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: locationField(func(v universe.Location) interface{} { return v.LocationId })},
+			"name":       &graphql.Field{Type: graphql.String, Resolve: locationField(func(v universe.Location) interface{} { return v.LocationName })},
+			"entryCount": &graphql.Field{Type: graphql.Int, Resolve: locationField(func(v universe.Location) interface{} { return v.DocumentCount })},
+			"indexedAt":  &graphql.Field{Type: graphql.DateTime, Resolve: locationField(func(v universe.Location) interface{} { return v.IndexedAt })},
+		},
+	})
+
+	itemTypeType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "ItemType",
+		Fields: graphql.Fields{
+			"id":   &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: itemTypeField(func(v itemType) interface{} { return v.Id })},
+			"name": &graphql.Field{Type: graphql.String, Resolve: itemTypeField(func(v itemType) interface{} { return v.Name })},
+		},
+	})
+
+	marketEntryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "MarketEntry",
+		Fields: graphql.Fields{
+			"buyPrice":        &graphql.Field{Type: graphql.Float, Resolve: entryField(func(o denormorder.DenormalizedOrder) interface{} { return o.BuyPrice })},
+			"sellPrice":       &graphql.Field{Type: graphql.Float, Resolve: entryField(func(o denormorder.DenormalizedOrder) interface{} { return o.SellPrice })},
+			"buyVolume":       &graphql.Field{Type: graphql.Int, Resolve: entryField(func(o denormorder.DenormalizedOrder) interface{} { return o.BuyVolume })},
+			"sellVolume":      &graphql.Field{Type: graphql.Int, Resolve: entryField(func(o denormorder.DenormalizedOrder) interface{} { return o.SellVolume })},
+			"spread":          &graphql.Field{Type: graphql.Float, Resolve: entryField(func(o denormorder.DenormalizedOrder) interface{} { return o.Spread })},
+			"margin":          &graphql.Field{Type: graphql.Float, Resolve: entryField(func(o denormorder.DenormalizedOrder) interface{} { return o.Margin })},
+			"indexedAt":       &graphql.Field{Type: graphql.DateTime, Resolve: entryField(func(o denormorder.DenormalizedOrder) interface{} { return nullableTime(o.IndexedAt) })},
+			"esiLastModified": &graphql.Field{Type: graphql.DateTime, Resolve: entryField(func(o denormorder.DenormalizedOrder) interface{} { return nullableTime(o.EsiLastModified) })},
+			"itemType": &graphql.Field{Type: graphql.NewNonNull(itemTypeType), Resolve: entryField(func(o denormorder.DenormalizedOrder) interface{} {
+				return itemType{Id: o.TypeId, Name: o.TypeName}
+			})},
+			"region": &graphql.Field{Type: regionType, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return notFoundAsNil(loaderFromContext(p.Context).getRegion(p.Source.(denormorder.DenormalizedOrder).RegionId))
+			}},
+			"system": &graphql.Field{Type: systemType, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return notFoundAsNil(loaderFromContext(p.Context).getSystem(p.Source.(denormorder.DenormalizedOrder).SystemId))
+			}},
+			"location": &graphql.Field{Type: locationType, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				o := p.Source.(denormorder.DenormalizedOrder)
+				return notFoundAsNil(loaderFromContext(p.Context).getLocationInSystem(o.LocationId, o.SystemId))
+			}},
+		},
+	})
+
+	marketPageType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "MarketPage",
+		Fields: graphql.Fields{
+			"total":  &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: pageField(func(v marketPage) interface{} { return v.Total })},
+			"limit":  &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: pageField(func(v marketPage) interface{} { return v.Limit })},
+			"offset": &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: pageField(func(v marketPage) interface{} { return v.Offset })},
+			"hasNext": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				page := p.Source.(marketPage)
+				return page.Offset+len(page.Entries) < page.Total, nil
+			}},
+			"entries": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(marketEntryType))), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(marketPage).Entries, nil
+			}},
+		},
+	})
+
+	searchEntries := func(location string, p graphql.ResolveParams) (interface{}, error) {
+		filter, err := createFilter(location, p.Args)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := denormorder.GetDenormalizedOrdersWithFilter(filter, client)
+
+		if err != nil {
+			return nil, err
+		}
+
+		// Like on the rest api, the demand for the region of the first entry plan its indexation
+		if len(result.Orders) > 0 {
+			client.Publish(context.Background(), namespace.Key("apiEvent"), result.Orders[0].RegionId)
+		}
+
+		return marketPage{Total: result.Total, Limit: filter.Limit, Offset: filter.Offset, Entries: result.Orders}, nil
+	}
+
+	// The types reference each other, the nested lookups are added once they all exist
+	regionType.AddFieldConfig("systems", &graphql.Field{
+		Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(systemType))),
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return loaderFromContext(p.Context).getSystemsInRegion(p.Source.(universe.Region).RegionId)
+		},
+	})
+	regionType.AddFieldConfig("entries", &graphql.Field{
+		Type: graphql.NewNonNull(marketPageType),
+		Args: entriesArgs(false),
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return searchEntries(fmt.Sprint(p.Source.(universe.Region).RegionId), p)
+		},
+	})
+
+	systemType.AddFieldConfig("region", &graphql.Field{
+		Type: regionType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return notFoundAsNil(loaderFromContext(p.Context).getRegion(p.Source.(universe.System).RegionId))
+		},
+	})
+	systemType.AddFieldConfig("locations", &graphql.Field{
+		Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(locationType))),
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return loaderFromContext(p.Context).getLocationsInSystem(p.Source.(universe.System).SystemId)
+		},
+	})
+	systemType.AddFieldConfig("entries", &graphql.Field{
+		Type: graphql.NewNonNull(marketPageType),
+		Args: entriesArgs(false),
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return searchEntries(fmt.Sprint(p.Source.(universe.System).SystemId), p)
+		},
+	})
+
+	locationType.AddFieldConfig("system", &graphql.Field{
+		Type: systemType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return notFoundAsNil(loaderFromContext(p.Context).getSystem(p.Source.(universe.Location).SystemId))
+		},
+	})
+	locationType.AddFieldConfig("region", &graphql.Field{
+		Type: regionType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return notFoundAsNil(loaderFromContext(p.Context).getRegion(p.Source.(universe.Location).RegionId))
+		},
+	})
+	locationType.AddFieldConfig("entries", &graphql.Field{
+		Type: graphql.NewNonNull(marketPageType),
+		Args: entriesArgs(false),
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return searchEntries(fmt.Sprint(p.Source.(universe.Location).LocationId), p)
+		},
+	})
+
+	itemTypeType.AddFieldConfig("entries", &graphql.Field{
+		Type: graphql.NewNonNull(marketPageType),
+		Args: itemTypeEntriesArgs,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			// The args can be shared with the other resolvers, the type is added to a copy
+			args := make(map[string]interface{}, len(p.Args)+1)
+			for name, value := range p.Args {
+				args[name] = value
+			}
+			args["typeIds"] = []interface{}{p.Source.(itemType).Id}
+			p.Args = args
+
+			location, _ := p.Args["location"].(string)
+
+			return searchEntries(location, p)
+		},
+	})
+
+	idArgs := graphql.FieldConfigArgument{"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)}}
+
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"regions": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(regionType))),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loaderFromContext(p.Context).getRegions()
+				},
+			},
+			"region": &graphql.Field{
+				Type: regionType,
+				Args: idArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return notFoundAsNil(loaderFromContext(p.Context).getRegion(p.Args["id"].(int)))
+				},
+			},
+			"system": &graphql.Field{
+				Type: systemType,
+				Args: idArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return notFoundAsNil(loaderFromContext(p.Context).getSystem(p.Args["id"].(int)))
+				},
+			},
+			"location": &graphql.Field{
+				Type: locationType,
+				Args: idArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return notFoundAsNil(loaderFromContext(p.Context).getLocation(p.Args["id"].(int)))
+				},
+			},
+			"itemType": &graphql.Field{
+				Type: itemTypeType,
+				Args: idArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					typeId := p.Args["id"].(int)
+					name, err := loaderFromContext(p.Context).getTypeName(typeId)
+
+					if err != nil || name == "" {
+						return nil, err
+					}
+
+					return itemType{Id: typeId, Name: name}, nil
+				},
+			},
+			"market": &graphql.Field{
+				Type: graphql.NewNonNull(marketPageType),
+				Args: entriesArgs(true),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					location, _ := p.Args["location"].(string)
+
+					return searchEntries(location, p)
+				},
+			},
+		},
+	})
+}
+
+// createFilter read the arguments of a list of entries, the values are checked by the validation of every api.
+func createFilter(location string, args map[string]interface{}) (denormorder.Filter, error) {
+	filter := denormorder.NewFilter(location)
+	filter.Limit = args["limit"].(int)
+	filter.Offset = args["offset"].(int)
+
+	if filter.Limit > maxLimit {
+		return denormorder.Filter{}, fmt.Errorf("limit must be between 1 and %d", maxLimit)
+	}
+
+	filter.TypeName, _ = args["typeName"].(string)
+	if val, ok := args["typeNameMatch"].(string); ok {
+		filter.TypeNameMatch = val
+	}
+
+	if ids, ok := args["typeIds"].([]interface{}); ok {
+		for _, id := range ids {
+			filter.TypeIds = append(filter.TypeIds, id.(int))
+		}
+	}
+
+	filter.SortBy, _ = args["sortBy"].(string)
+	filter.SortOrder, _ = args["order"].(string)
+
+	for name, value := range map[string]*float64{
+		"minBuyPrice":  &filter.MinBuyPrice,
+		"maxBuyPrice":  &filter.MaxBuyPrice,
+		"minSellPrice": &filter.MinSellPrice,
+		"maxSellPrice": &filter.MaxSellPrice,
+	} {
+		if val, ok := args[name].(float64); ok {
+			*value = val
+		}
+	}
+
+	for name, value := range map[string]*int64{
+		"minBuyVolume":  &filter.MinBuyVolume,
+		"maxBuyVolume":  &filter.MaxBuyVolume,
+		"minSellVolume": &filter.MinSellVolume,
+		"maxSellVolume": &filter.MaxSellVolume,
+	} {
+		if val, ok := args[name].(int); ok {
+			*value = int64(val)
+		}
+	}
+
+	if err := filter.Validate(); err != nil {
+		return denormorder.Filter{}, err
+	}
+
+	return filter, nil
+}
+
+// The fields that only read their source go through these helpers
+
+func regionField(resolve func(v universe.Region) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) { return resolve(p.Source.(universe.Region)), nil }
+}
+
+func systemField(resolve func(v universe.System) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) { return resolve(p.Source.(universe.System)), nil }
+}
+
+func locationField(resolve func(v universe.Location) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) { return resolve(p.Source.(universe.Location)), nil }
+}
+
+func itemTypeField(resolve func(v itemType) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) { return resolve(p.Source.(itemType)), nil }
+}
+
+func pageField(resolve func(v marketPage) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) { return resolve(p.Source.(marketPage)), nil }
+}
+
+func entryField(resolve func(o denormorder.DenormalizedOrder) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		return resolve(p.Source.(denormorder.DenormalizedOrder)), nil
+	}
+}
+
+// notFoundAsNil resolve an unknown object to null instead of an error.
+func notFoundAsNil[T any](v T, err error) (interface{}, error) {
+	if errors.Is(err, universe.ErrNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+func nullableTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+
+	return t
+}
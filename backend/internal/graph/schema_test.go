@@ -0,0 +1,46 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+func TestItemTypeEntriesArgs(t *testing.T) {
+	schema, err := NewSchema(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		query     string
+		wantError string
+	}{
+		{"type of the parent", `{ itemType(id: 34) { entries(location: "jita") { total } } }`, ""},
+		{"other types", `{ itemType(id: 34) { entries(location: "jita", typeIds: [35]) { total } } }`, `Unknown argument "typeIds"`},
+		{"types of the market", `{ market(location: "jita", typeIds: [34, 35]) { total } }`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document, errParse := parser.Parse(parser.ParseParams{Source: tt.query})
+			if errParse != nil {
+				t.Fatal(errParse)
+			}
+
+			// Only the validation is run, the resolvers would need redis
+			errs := graphql.ValidateDocument(&schema.schema, document, nil).Errors
+
+			if tt.wantError == "" && len(errs) > 0 {
+				t.Fatalf("unexpected errors %v", errs)
+			}
+
+			if tt.wantError != "" && (len(errs) == 0 || !strings.Contains(errs[0].Message, tt.wantError)) {
+				t.Errorf("got %v, want an error about %s", errs, tt.wantError)
+			}
+		})
+	}
+}
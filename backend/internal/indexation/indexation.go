@@ -0,0 +1,56 @@
+package indexation
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/hyoa/wall-eve/backend/internal/namespace"
+)
+
+// The status of a message of indexationFinished, a message without status is a success.
+const (
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// RegionIndexation describe the last indexation that succeeded for a region.
+type RegionIndexation struct {
+	IndexedAt time.Time
+	// EsiLastModified is when ESI built the orders snapshot that has been indexed
+	EsiLastModified time.Time
+}
+
+func SaveRegionIndexation(regionId int, regionIndexation RegionIndexation, client *goredis.Client) error {
+	return client.HSet(
+		context.Background(),
+		namespace.Key("regionIndexation", regionId),
+		"indexedAt", regionIndexation.IndexedAt.Unix(),
+		"esiLastModified", regionIndexation.EsiLastModified.Unix(),
+	).Err()
+}
+
+// GetRegionIndexation return a zero RegionIndexation if the region has never been indexed.
+func GetRegionIndexation(regionId int, client *goredis.Client) (RegionIndexation, error) {
+	values, err := client.HGetAll(context.Background(), namespace.Key("regionIndexation", regionId)).Result()
+
+	if err != nil {
+		return RegionIndexation{}, err
+	}
+
+	return RegionIndexation{
+		IndexedAt:       parseTimestamp(values["indexedAt"]),
+		EsiLastModified: parseTimestamp(values["esiLastModified"]),
+	}, nil
+}
+
+func parseTimestamp(val string) time.Time {
+	ts, errParse := strconv.ParseInt(val, 10, 64)
+
+	if errParse != nil || ts <= 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(ts, 0).UTC()
+}
@@ -0,0 +1,215 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: market.proto
+
+package marketpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Market_SearchMarket_FullMethodName = "/walleve.market.v1.Market/SearchMarket"
+	Market_GetItem_FullMethodName      = "/walleve.market.v1.Market/GetItem"
+	Market_WatchItems_FullMethodName   = "/walleve.market.v1.Market/WatchItems"
+)
+
+// MarketClient is the client API for Market service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type MarketClient interface {
+	SearchMarket(ctx context.Context, in *SearchMarketRequest, opts ...grpc.CallOption) (*SearchMarketResponse, error)
+	GetItem(ctx context.Context, in *GetItemRequest, opts ...grpc.CallOption) (*GetItemResponse, error)
+	// WatchItems send the current value of every watched item that exists, then a new
+	// value each time the indexer write different prices or volumes for one of them.
+	WatchItems(ctx context.Context, in *WatchItemsRequest, opts ...grpc.CallOption) (Market_WatchItemsClient, error)
+}
+
+type marketClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMarketClient(cc grpc.ClientConnInterface) MarketClient {
+	return &marketClient{cc}
+}
+
+func (c *marketClient) SearchMarket(ctx context.Context, in *SearchMarketRequest, opts ...grpc.CallOption) (*SearchMarketResponse, error) {
+	out := new(SearchMarketResponse)
+	err := c.cc.Invoke(ctx, Market_SearchMarket_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *marketClient) GetItem(ctx context.Context, in *GetItemRequest, opts ...grpc.CallOption) (*GetItemResponse, error) {
+	out := new(GetItemResponse)
+	err := c.cc.Invoke(ctx, Market_GetItem_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *marketClient) WatchItems(ctx context.Context, in *WatchItemsRequest, opts ...grpc.CallOption) (Market_WatchItemsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Market_ServiceDesc.Streams[0], Market_WatchItems_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &marketWatchItemsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Market_WatchItemsClient interface {
+	Recv() (*MarketEntry, error)
+	grpc.ClientStream
+}
+
+type marketWatchItemsClient struct {
+	grpc.ClientStream
+}
+
+func (x *marketWatchItemsClient) Recv() (*MarketEntry, error) {
+	m := new(MarketEntry)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// MarketServer is the server API for Market service.
+// All implementations must embed UnimplementedMarketServer
+// for forward compatibility
+type MarketServer interface {
+	SearchMarket(context.Context, *SearchMarketRequest) (*SearchMarketResponse, error)
+	GetItem(context.Context, *GetItemRequest) (*GetItemResponse, error)
+	// WatchItems send the current value of every watched item that exists, then a new
+	// value each time the indexer write different prices or volumes for one of them.
+	WatchItems(*WatchItemsRequest, Market_WatchItemsServer) error
+	mustEmbedUnimplementedMarketServer()
+}
+
+// UnimplementedMarketServer must be embedded to have forward compatible implementations.
+type UnimplementedMarketServer struct {
+}
+
+func (UnimplementedMarketServer) SearchMarket(context.Context, *SearchMarketRequest) (*SearchMarketResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchMarket not implemented")
+}
+func (UnimplementedMarketServer) GetItem(context.Context, *GetItemRequest) (*GetItemResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetItem not implemented")
+}
+func (UnimplementedMarketServer) WatchItems(*WatchItemsRequest, Market_WatchItemsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchItems not implemented")
+}
+func (UnimplementedMarketServer) mustEmbedUnimplementedMarketServer() {}
+
+// UnsafeMarketServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MarketServer will
+// result in compilation errors.
+type UnsafeMarketServer interface {
+	mustEmbedUnimplementedMarketServer()
+}
+
+func RegisterMarketServer(s grpc.ServiceRegistrar, srv MarketServer) {
+	s.RegisterService(&Market_ServiceDesc, srv)
+}
+
+func _Market_SearchMarket_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchMarketRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MarketServer).SearchMarket(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Market_SearchMarket_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MarketServer).SearchMarket(ctx, req.(*SearchMarketRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Market_GetItem_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetItemRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MarketServer).GetItem(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Market_GetItem_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MarketServer).GetItem(ctx, req.(*GetItemRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Market_WatchItems_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchItemsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MarketServer).WatchItems(m, &marketWatchItemsServer{stream})
+}
+
+type Market_WatchItemsServer interface {
+	Send(*MarketEntry) error
+	grpc.ServerStream
+}
+
+type marketWatchItemsServer struct {
+	grpc.ServerStream
+}
+
+func (x *marketWatchItemsServer) Send(m *MarketEntry) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Market_ServiceDesc is the grpc.ServiceDesc for Market service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Market_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "walleve.market.v1.Market",
+	HandlerType: (*MarketServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SearchMarket",
+			Handler:    _Market_SearchMarket_Handler,
+		},
+		{
+			MethodName: "GetItem",
+			Handler:    _Market_GetItem_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchItems",
+			Handler:       _Market_WatchItems_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "market.proto",
+}
@@ -0,0 +1,401 @@
+package marketwatch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/hyoa/wall-eve/backend/internal/denormorder"
+	"github.com/hyoa/wall-eve/backend/internal/namespace"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	MaxItems = 100
+	// MaxFilterItems is the number of items matching a filter that are watched
+	MaxFilterItems = 10000
+	// The items matching a filter are read again by batches after each indexation
+	filterBatchSize = 1000
+	// watcherBuffer is the number of reads a watcher can be late of before it is dropped
+	watcherBuffer = 16
+	// resubscribeDelay is the wait before subscribing again when the subscription failed
+	resubscribeDelay = time.Second
+	// anyRegion wake every group, eg: when the updates may have been missed
+	anyRegion = 0
+)
+
+var errTooSlow = errors.New("market watcher is too slow to read the changes")
+
+type ItemKey struct {
+	LocationId int
+	TypeId     int
+}
+
+// NotifyUpdate tell the watchers that the items of a region have been written again.
+func NotifyUpdate(regionId int, client *goredis.Client) error {
+	return client.Publish(context.Background(), namespace.Key("marketUpdated"), regionId).Err()
+}
+
+// readFunc read the items that may have changed with the indexation of the regions, anyRegion included
+// when every item must be read. last is the values already seen.
+type readFunc func(regionIds map[int]bool, last map[ItemKey]denormorder.DenormalizedOrder) ([]denormorder.DenormalizedOrder, error)
+
+// group is a read shared by the watchers of the same filter, the items are read once for all of them
+// and only the changes are handed to each one.
+type group struct {
+	read      readFunc
+	notifyNew func() bool
+	last      map[ItemKey]denormorder.DenormalizedOrder
+	pending   map[int]bool
+	wake      chan struct{}
+	stop      chan struct{}
+	watchers  map[*watcher]bool
+}
+
+type watcher struct {
+	changes chan []denormorder.DenormalizedOrder
+	err     chan error
+}
+
+// Hub share one subscription to the updates of the indexer between every watcher of the process.
+type Hub struct {
+	client   *goredis.Client
+	mu       sync.Mutex
+	groups   map[string]*group
+	watchIds uint64
+}
+
+func NewHub(client *goredis.Client) *Hub {
+	return &Hub{
+		client: client,
+		groups: make(map[string]*group),
+	}
+}
+
+// Run subscribe to the regions written by the indexer and wake the groups that watch them, it never returns.
+func (h *Hub) Run() {
+	for {
+		if errSubscribe := h.subscribe(); errSubscribe != nil {
+			log.Errorf("Market updates subscription failed: %s", errSubscribe.Error())
+		}
+
+		time.Sleep(resubscribeDelay)
+	}
+}
+
+func (h *Hub) subscribe() error {
+	pubsub := h.client.Subscribe(context.Background(), namespace.Key("marketUpdated"))
+	defer pubsub.Close()
+
+	if _, errReceive := pubsub.Receive(context.Background()); errReceive != nil {
+		return errReceive
+	}
+
+	// The updates sent while the hub was not subscribed are lost, every item is read again
+	h.notify(anyRegion)
+
+	for msg := range pubsub.Channel() {
+		regionId, _ := strconv.Atoi(msg.Payload)
+		h.notify(regionId)
+	}
+
+	return errors.New("market updates subscription closed")
+}
+
+// notify wake every group, a group already reading will read the region again once done.
+func (h *Hub) notify(regionId int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, g := range h.groups {
+		g.pending[regionId] = true
+
+		select {
+		case g.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Watch hand to fn the current value of the items that exist, then every new value written by the
+// indexer until ctx is done or fn fail. Only a change of the prices or the volumes is sent again.
+func (h *Hub) Watch(ctx context.Context, items []ItemKey, fn func(o denormorder.DenormalizedOrder) error) error {
+	// The current values are sent to each watcher, so the items of a watcher are not shared
+	key := fmt.Sprintf("items:%d", atomic.AddUint64(&h.watchIds, 1))
+
+	return h.watch(ctx, key, func() *group {
+		return newGroup(h.readItems(items), func() bool { return true })
+	}, fn)
+}
+
+// WatchFilter hand to fn the items matching the filter each time the indexer change their prices or volumes,
+// until ctx is done or fn fail. The current values are not sent, they can be read with the same filter.
+// The watchers of the same filter share its read, it is only done when the indexed region has the location
+// of the filter, for at most MaxFilterItems.
+func (h *Hub) WatchFilter(ctx context.Context, filter denormorder.Filter, fn func(o denormorder.DenormalizedOrder) error) error {
+	if filter.Limit < 1 || filter.Limit > MaxFilterItems {
+		filter.Limit = MaxFilterItems
+	}
+
+	return h.watch(ctx, "filter:"+filter.CacheKey(), func() *group {
+		initialized := false
+
+		return newGroup(h.readFilter(filter), func() bool {
+			notifyNew := initialized
+			initialized = true
+
+			return notifyNew
+		})
+	}, fn)
+}
+
+func newGroup(read readFunc, notifyNew func() bool) *group {
+	return &group{
+		read:      read,
+		notifyNew: notifyNew,
+		last:      make(map[ItemKey]denormorder.DenormalizedOrder),
+		pending:   map[int]bool{anyRegion: true},
+		wake:      make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+		watchers:  make(map[*watcher]bool),
+	}
+}
+
+func (h *Hub) watch(ctx context.Context, key string, create func() *group, fn func(o denormorder.DenormalizedOrder) error) error {
+	w := &watcher{
+		changes: make(chan []denormorder.DenormalizedOrder, watcherBuffer),
+		err:     make(chan error, 1),
+	}
+
+	h.join(key, w, create)
+	defer h.leave(key, w)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-w.err:
+			return err
+		case changes := <-w.changes:
+			for _, o := range changes {
+				if errFn := fn(o); errFn != nil {
+					return errFn
+				}
+			}
+		}
+	}
+}
+
+func (h *Hub) join(key string, w *watcher, create func() *group) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	g, ok := h.groups[key]
+	if !ok {
+		g = create()
+		h.groups[key] = g
+		g.wake <- struct{}{}
+		go h.run(key, g)
+	}
+
+	g.watchers[w] = true
+}
+
+// leave stop the group once its last watcher is gone.
+func (h *Hub) leave(key string, w *watcher) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	g, ok := h.groups[key]
+	if !ok || !g.watchers[w] {
+		return
+	}
+
+	delete(g.watchers, w)
+	if len(g.watchers) == 0 {
+		delete(h.groups, key)
+		close(g.stop)
+	}
+}
+
+func (h *Hub) run(key string, g *group) {
+	for {
+		select {
+		case <-g.stop:
+			return
+		case <-g.wake:
+		}
+
+		h.mu.Lock()
+		regionIds := g.pending
+		g.pending = make(map[int]bool)
+		h.mu.Unlock()
+
+		orders, errRead := g.read(regionIds, g.last)
+
+		if errRead != nil {
+			h.fail(key, g, errRead)
+			return
+		}
+
+		h.send(key, g, g.changes(orders))
+	}
+}
+
+// changes remember the orders read and return the ones with new prices or volumes.
+func (g *group) changes(orders []denormorder.DenormalizedOrder) []denormorder.DenormalizedOrder {
+	notifyNew := g.notifyNew()
+	changes := make([]denormorder.DenormalizedOrder, 0)
+
+	for _, order := range orders {
+		key := ItemKey{LocationId: order.LocationId, TypeId: order.TypeId}
+		previous, found := g.last[key]
+		g.last[key] = order
+
+		if (found && !sameValues(previous, order)) || (!found && notifyNew) {
+			changes = append(changes, order)
+		}
+	}
+
+	return changes
+}
+
+// send hand the changes to the watchers of the group, a watcher that is too late is dropped
+// so it never hold the others.
+func (h *Hub) send(key string, g *group, changes []denormorder.DenormalizedOrder) {
+	if len(changes) == 0 {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for w := range g.watchers {
+		select {
+		case w.changes <- changes:
+		default:
+			delete(g.watchers, w)
+			w.err <- errTooSlow
+		}
+	}
+
+	if len(g.watchers) == 0 && h.groups[key] == g {
+		delete(h.groups, key)
+		close(g.stop)
+	}
+}
+
+// fail end every watcher of the group with the error of its read.
+func (h *Hub) fail(key string, g *group, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for w := range g.watchers {
+		delete(g.watchers, w)
+		w.err <- err
+	}
+
+	if h.groups[key] == g {
+		delete(h.groups, key)
+	}
+}
+
+// readItems only read again the items of the indexed regions, the unknown ones are always read.
+func (h *Hub) readItems(items []ItemKey) readFunc {
+	return func(regionIds map[int]bool, last map[ItemKey]denormorder.DenormalizedOrder) ([]denormorder.DenormalizedOrder, error) {
+		orders := make([]denormorder.DenormalizedOrder, 0)
+
+		for _, item := range items {
+			previous, found := last[item]
+
+			if found && !regionIds[anyRegion] && !regionIds[previous.RegionId] {
+				continue
+			}
+
+			order, _, errGet := denormorder.GetDenormalizedOrder(item.LocationId, item.TypeId, h.client)
+
+			if errors.Is(errGet, denormorder.ErrNotFound) {
+				continue
+			}
+
+			if errGet != nil {
+				return nil, errGet
+			}
+
+			orders = append(orders, order)
+		}
+
+		return orders, nil
+	}
+}
+
+// readFilter read the items matching the filter when one of the indexed regions has the location of the filter.
+func (h *Hub) readFilter(filter denormorder.Filter) readFunc {
+	regions := make(map[int]bool)
+
+	return func(regionIds map[int]bool, last map[ItemKey]denormorder.DenormalizedOrder) ([]denormorder.DenormalizedOrder, error) {
+		// A region that was not indexed yet can have the location now, so the unknown ones are checked again
+		if !containsAll(regions, regionIds) {
+			if errRegions := readRegions(filter.Location, regions, h.client); errRegions != nil {
+				return nil, errRegions
+			}
+		}
+
+		if !regionIds[anyRegion] && !containsAny(regions, regionIds) {
+			return nil, nil
+		}
+
+		orders := make([]denormorder.DenormalizedOrder, 0)
+		errStream := denormorder.StreamDenormalizedOrdersWithFilter(filter, filterBatchSize, h.client, func(o denormorder.DenormalizedOrder) error {
+			orders = append(orders, o)
+
+			return nil
+		})
+
+		return orders, errStream
+	}
+}
+
+// readRegions add the regions having the location to regions.
+func readRegions(location string, regions map[int]bool, client *goredis.Client) error {
+	regionIds, err := denormorder.RegionsOfLocation(location, client)
+
+	if err != nil {
+		return err
+	}
+
+	for _, regionId := range regionIds {
+		regions[regionId] = true
+	}
+
+	return nil
+}
+
+func containsAll(regions map[int]bool, regionIds map[int]bool) bool {
+	for regionId := range regionIds {
+		if !regions[regionId] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func containsAny(regions map[int]bool, regionIds map[int]bool) bool {
+	for regionId := range regionIds {
+		if regions[regionId] {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sameValues(a denormorder.DenormalizedOrder, b denormorder.DenormalizedOrder) bool {
+	return a.BuyPrice == b.BuyPrice && a.SellPrice == b.SellPrice && a.BuyVolume == b.BuyVolume && a.SellVolume == b.SellVolume
+}
@@ -0,0 +1,183 @@
+package marketwatch
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyoa/wall-eve/backend/internal/denormorder"
+)
+
+// fakeRead return the current orders of a test and count the reads.
+type fakeRead struct {
+	mu     sync.Mutex
+	orders []denormorder.DenormalizedOrder
+	err    error
+	reads  int
+	done   chan struct{}
+}
+
+func newFakeRead(orders ...denormorder.DenormalizedOrder) *fakeRead {
+	return &fakeRead{orders: orders, done: make(chan struct{}, 100)}
+}
+
+func (f *fakeRead) read(regionIds map[int]bool, last map[ItemKey]denormorder.DenormalizedOrder) ([]denormorder.DenormalizedOrder, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.reads++
+	f.done <- struct{}{}
+
+	return append([]denormorder.DenormalizedOrder(nil), f.orders...), f.err
+}
+
+func (f *fakeRead) set(orders ...denormorder.DenormalizedOrder) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.orders = orders
+}
+
+func (f *fakeRead) wait(t *testing.T) {
+	t.Helper()
+
+	select {
+	case <-f.done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("no read")
+	}
+}
+
+func filterGroup(f *fakeRead) func() *group {
+	return func() *group {
+		initialized := false
+
+		return newGroup(f.read, func() bool {
+			notifyNew := initialized
+			initialized = true
+
+			return notifyNew
+		})
+	}
+}
+
+func waitWatchers(t *testing.T, h *Hub, key string, count int) {
+	t.Helper()
+
+	for i := 0; i < 200; i++ {
+		h.mu.Lock()
+		g, ok := h.groups[key]
+		joined := ok && len(g.watchers) == count
+		h.mu.Unlock()
+
+		if joined {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("%d watchers never joined %s", count, key)
+}
+
+func tritanium(sellPrice float64) denormorder.DenormalizedOrder {
+	return denormorder.DenormalizedOrder{RegionId: 10000002, LocationId: 60003760, TypeId: 34, SellPrice: sellPrice}
+}
+
+func TestHubShareTheReadOfAFilter(t *testing.T) {
+	h := NewHub(nil)
+	f := newFakeRead(tritanium(5))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	received := make(chan denormorder.DenormalizedOrder, 10)
+	for i := 0; i < 2; i++ {
+		go h.watch(ctx, "filter:jita", filterGroup(f), func(o denormorder.DenormalizedOrder) error {
+			received <- o
+			return nil
+		})
+	}
+
+	f.wait(t)
+	waitWatchers(t, h, "filter:jita", 2)
+
+	f.set(tritanium(6))
+	h.notify(10000002)
+	f.wait(t)
+
+	for i := 0; i < 2; i++ {
+		select {
+		case o := <-received:
+			if o.SellPrice != 6 {
+				t.Errorf("got sell price %.0f, want 6", o.SellPrice)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("a watcher did not receive the change")
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.reads != 2 {
+		t.Errorf("got %d reads, want 2, the initial one and the one of the update", f.reads)
+	}
+
+	select {
+	case o := <-received:
+		t.Errorf("got an unexpected change %+v, the initial values are not sent", o)
+	default:
+	}
+}
+
+func TestHubDropTheSlowWatchers(t *testing.T) {
+	h := NewHub(nil)
+	f := newFakeRead(tritanium(0))
+	release := make(chan struct{})
+
+	errWatch := make(chan error, 1)
+	go func() {
+		errWatch <- h.watch(context.Background(), "filter:jita", filterGroup(f), func(o denormorder.DenormalizedOrder) error {
+			<-release
+			return nil
+		})
+	}()
+
+	f.wait(t)
+	for price := 1; price <= watcherBuffer+2; price++ {
+		f.set(tritanium(float64(price)))
+		h.notify(10000002)
+		f.wait(t)
+	}
+	close(release)
+
+	select {
+	case err := <-errWatch:
+		if !errors.Is(err, errTooSlow) {
+			t.Errorf("got %v, want %v", err, errTooSlow)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("the slow watcher was not dropped")
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.groups) != 0 {
+		t.Errorf("got %d groups, want none once the last watcher is dropped", len(h.groups))
+	}
+}
+
+func TestHubEndTheWatchersOnReadError(t *testing.T) {
+	h := NewHub(nil)
+	f := newFakeRead()
+	f.err = errors.New("index not found")
+
+	err := h.watch(context.Background(), "filter:jita", filterGroup(f), func(o denormorder.DenormalizedOrder) error {
+		return nil
+	})
+
+	if err == nil || err.Error() != "index not found" {
+		t.Errorf("got %v, want the error of the read", err)
+	}
+}
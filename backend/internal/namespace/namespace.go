@@ -0,0 +1,46 @@
+package namespace
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+var (
+	prefix     string
+	prefixOnce sync.Once
+)
+
+// Prefix return the namespace of every redis key, stream, channel and index, read once from REDIS_NAMESPACE.
+// Without it, a datasource other than tranquility is used as namespace so its data never mix with
+// the tranquility ones, and tranquility keep the original names.
+func Prefix() string {
+	prefixOnce.Do(func() {
+		val := strings.Trim(os.Getenv("REDIS_NAMESPACE"), ":")
+
+		if datasource := os.Getenv("ESI_DATASOURCE"); val == "" && datasource != "" && datasource != "tranquility" {
+			val = datasource
+		}
+
+		if val != "" {
+			prefix = val + ":"
+		}
+	})
+
+	return prefix
+}
+
+// Key put the name and its parts, separated by ":", under the namespace.
+// eg: Key("denormalizedOrders", 60014692, 1137) => singularity:denormalizedOrders:60014692:1137
+func Key(name string, parts ...interface{}) string {
+	var b strings.Builder
+	b.WriteString(Prefix())
+	b.WriteString(name)
+
+	for _, part := range parts {
+		b.WriteString(fmt.Sprintf(":%v", part))
+	}
+
+	return b.String()
+}
@@ -0,0 +1,50 @@
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+const (
+	ChannelDiscord = "discord"
+	ChannelSlack   = "slack"
+	ChannelWebhook = "webhook"
+)
+
+type ChannelConfig struct {
+	Type   string `json:"type"`
+	Url    string `json:"url"`
+	Secret string `json:"secret"`
+}
+
+// RouteConfig send an event type to a channel, with the default template of the event when Template is empty.
+type RouteConfig struct {
+	Channel  string `json:"channel"`
+	Template string `json:"template"`
+}
+
+type Config struct {
+	Channels map[string]ChannelConfig `json:"channels"`
+	Events   map[string][]RouteConfig `json:"events"`
+	// MaxRetries is the number of retries after a failed delivery, a negative value disable them
+	MaxRetries int `json:"maxRetries"`
+	// Timeout of a delivery, eg: "10s"
+	Timeout string `json:"timeout"`
+}
+
+// LoadConfig read a json config, the ${VAR} in it are replaced by the environment so the secrets can stay out of the file.
+func LoadConfig(path string) (Config, error) {
+	content, errRead := os.ReadFile(path)
+
+	if errRead != nil {
+		return Config{}, errRead
+	}
+
+	var config Config
+	if errUnmarshal := json.Unmarshal([]byte(os.ExpandEnv(string(content))), &config); errUnmarshal != nil {
+		return Config{}, fmt.Errorf("invalid notifier config %s: %w", path, errUnmarshal)
+	}
+
+	return config, nil
+}
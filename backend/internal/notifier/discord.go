@@ -0,0 +1,30 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// Discord refuse a content longer than 2000 characters.
+const maxDiscordContent = 2000
+
+type Discord struct {
+	url        string
+	httpClient *http.Client
+}
+
+func NewDiscord(url string, httpClient *http.Client) *Discord {
+	return &Discord{url: url, httpClient: httpClient}
+}
+
+func (d *Discord) Notify(ctx context.Context, message Message) error {
+	content := []rune(message.Text)
+	if len(content) > maxDiscordContent {
+		content = append(content[:maxDiscordContent-1], '…')
+	}
+
+	body, _ := json.Marshal(map[string]string{"content": string(content)})
+
+	return postJSON(ctx, d.httpClient, d.url, body, nil)
+}
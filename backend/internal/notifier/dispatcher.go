@@ -0,0 +1,245 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/hyoa/wall-eve/backend/internal/namespace"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultMaxRetries = 3
+	DefaultRetryDelay = time.Second
+	DefaultTimeout    = 10 * time.Second
+
+	maxRetryDelay  = time.Minute
+	maxDeliveryLog = 10000
+	maxDeadLetters = 10000
+
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// DefaultTemplates are used by the routes without a template, the data of the event is the dot.
+var DefaultTemplates = map[string]string{
+	EventIndexationFailed: "Indexation failed for region {{.regionId}}: {{.error}}",
+	EventRegionAdded:      "Region {{.regionName}} ({{.regionId}}) is now indexed",
+	EventAlertFired:       "Alert {{.ruleName}} fired for {{len .matches}} items in region {{.regionId}}",
+}
+
+type route struct {
+	channel  string
+	notifier Notifier
+	template *template.Template
+}
+
+// Dispatcher render the events for the channels of their type and deliver them.
+type Dispatcher struct {
+	routes     map[string][]route
+	maxRetries int
+	retryDelay time.Duration
+	client     *goredis.Client
+}
+
+func NewDispatcher(config Config, client *goredis.Client) (*Dispatcher, error) {
+	timeout := DefaultTimeout
+	if config.Timeout != "" {
+		v, err := time.ParseDuration(config.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %s: %w", config.Timeout, err)
+		}
+		timeout = v
+	}
+
+	httpClient := &http.Client{Timeout: timeout}
+
+	notifiers := make(map[string]Notifier)
+	for name, channel := range config.Channels {
+		if channel.Url == "" {
+			return nil, fmt.Errorf("channel %s has no url", name)
+		}
+
+		switch channel.Type {
+		case ChannelDiscord:
+			notifiers[name] = NewDiscord(channel.Url, httpClient)
+		case ChannelSlack:
+			notifiers[name] = NewSlack(channel.Url, httpClient)
+		case ChannelWebhook:
+			notifiers[name] = NewWebhook(channel.Url, channel.Secret, httpClient)
+		default:
+			return nil, fmt.Errorf("channel %s has an unknown type %s, it must be one of discord, slack or webhook", name, channel.Type)
+		}
+	}
+
+	d := &Dispatcher{
+		routes:     make(map[string][]route),
+		maxRetries: config.MaxRetries,
+		retryDelay: DefaultRetryDelay,
+		client:     client,
+	}
+
+	if d.maxRetries == 0 {
+		d.maxRetries = DefaultMaxRetries
+	} else if d.maxRetries < 0 {
+		d.maxRetries = 0
+	}
+
+	for eventType, routes := range config.Events {
+		for _, r := range routes {
+			n, ok := notifiers[r.Channel]
+			if !ok {
+				return nil, fmt.Errorf("event %s is sent to an unknown channel %s", eventType, r.Channel)
+			}
+
+			text := r.Template
+			if text == "" {
+				text = DefaultTemplates[eventType]
+			}
+			if text == "" {
+				text = "{{.type}}"
+			}
+
+			tpl, errParse := template.New(eventType + ":" + r.Channel).Parse(text)
+			if errParse != nil {
+				return nil, fmt.Errorf("invalid template of event %s for channel %s: %w", eventType, r.Channel, errParse)
+			}
+
+			d.routes[eventType] = append(d.routes[eventType], route{channel: r.Channel, notifier: n, template: tpl})
+		}
+	}
+
+	return d, nil
+}
+
+// Dispatch deliver the event to each of its channels, a failed delivery does not stop the others.
+func (d *Dispatcher) Dispatch(ctx context.Context, event Event) {
+	for _, r := range d.routes[event.Type] {
+		text, errRender := render(r.template, event)
+
+		if errRender != nil {
+			d.logDelivery(event, r.channel, DeliveryFailed, 0, errRender)
+			d.deadLetter(event, r.channel, "", 0, errRender)
+			continue
+		}
+
+		attempts, errDeliver := d.deliver(ctx, r.notifier, Message{Event: event, Text: text})
+
+		if errDeliver != nil {
+			d.logDelivery(event, r.channel, DeliveryFailed, attempts, errDeliver)
+			d.deadLetter(event, r.channel, text, attempts, errDeliver)
+			continue
+		}
+
+		d.logDelivery(event, r.channel, DeliveryDelivered, attempts, nil)
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, n Notifier, message Message) (int, error) {
+	var err error
+
+	for attempt := 0; attempt <= d.maxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return attempt, ctx.Err()
+			case <-time.After(d.backoff(attempt - 1)):
+			}
+		}
+
+		if err = n.Notify(ctx, message); err == nil || !isRetryable(err) {
+			return attempt + 1, err
+		}
+	}
+
+	return d.maxRetries + 1, err
+}
+
+// backoff is exponential with jitter, capped to maxRetryDelay.
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	delay := d.retryDelay << attempt
+
+	if delay <= 0 || delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+func (d *Dispatcher) logDelivery(event Event, channel string, status string, attempts int, err error) {
+	values := []interface{}{"eventId", event.Id, "type", event.Type, "channel", channel, "status", status, "attempts", attempts}
+
+	if err != nil {
+		values = append(values, "error", err.Error())
+		log.Errorf("Unable to deliver %s %s to %s: %s", event.Type, event.Id, channel, err.Error())
+	} else {
+		log.Infof("Delivered %s %s to %s", event.Type, event.Id, channel)
+	}
+
+	errLog := d.client.XAdd(context.Background(), &goredis.XAddArgs{
+		Stream: namespace.Key("notificationDeliveries"),
+		MaxLen: maxDeliveryLog,
+		Approx: true,
+		Values: values,
+	}).Err()
+
+	if errLog != nil {
+		log.Errorln(errLog)
+	}
+}
+
+// deadLetter keep the whole event of a delivery that failed, the delivery log only has its id and
+// the events stream is trimmed, so it can be read again to send it by hand.
+func (d *Dispatcher) deadLetter(event Event, channel string, text string, attempts int, err error) {
+	data, errMarshal := json.Marshal(event.Data)
+
+	if errMarshal != nil {
+		log.Errorf("Unable to keep the failed event %s %s for %s: %s", event.Type, event.Id, channel, errMarshal.Error())
+		return
+	}
+
+	log.Errorf("Gave up the delivery of %s %s to %s after %d attempts: %s", event.Type, event.Id, channel, attempts, data)
+
+	errAdd := d.client.XAdd(context.Background(), &goredis.XAddArgs{
+		Stream: namespace.Key("notificationDeadLetters"),
+		MaxLen: maxDeadLetters,
+		Approx: true,
+		Values: []interface{}{
+			"eventId", event.Id,
+			"type", event.Type,
+			"occurredAt", event.OccurredAt.Unix(),
+			"data", data,
+			"channel", channel,
+			"message", text,
+			"attempts", attempts,
+			"error", err.Error(),
+		},
+	}).Err()
+
+	if errAdd != nil {
+		log.Errorln(errAdd)
+	}
+}
+
+func render(tpl *template.Template, event Event) (string, error) {
+	data := make(map[string]interface{}, len(event.Data)+2)
+	for k, v := range event.Data {
+		data[k] = v
+	}
+	data["type"] = event.Type
+	data["occurredAt"] = event.OccurredAt
+
+	var b strings.Builder
+	if err := tpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,78 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+)
+
+type StatusError struct {
+	Url        string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s answered %d", e.Url, e.StatusCode)
+}
+
+func postJSON(ctx context.Context, httpClient *http.Client, url string, body []byte, headers map[string]string) error {
+	req, errReq := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+
+	if errReq != nil {
+		return errReq
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	res, errDo := httpClient.Do(req)
+
+	if errDo != nil {
+		return errDo
+	}
+
+	defer res.Body.Close()
+	io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		// The url of a Discord or Slack webhook is a secret, it must not end in the delivery log
+		return &StatusError{Url: req.URL.Host, StatusCode: res.StatusCode}
+	}
+
+	return nil
+}
+
+// isRetryable is true for a transport error, a rate limit or a server error. Any other error, eg: a 4xx,
+// a body that can not be encoded, a malformed url or a certificate that is not trusted, would fail the same way again.
+func isRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500 || statusErr.StatusCode == http.StatusTooManyRequests
+	}
+
+	// Every error of the http client is an url.Error, that is a net.Error whatever its cause
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	// The connection closed by the server before the end of the response is an EOF
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
@@ -0,0 +1,60 @@
+package notifier
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+)
+
+// timeoutError is what the http client return when its timeout expire.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "Client.Timeout exceeded while awaiting headers" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func urlError(err error) error {
+	return &url.Error{Op: "Post", URL: "https://example.com", Err: err}
+}
+
+func TestIsRetryable(t *testing.T) {
+	_, errMarshal := json.Marshal(math.Inf(1))
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"server error", &StatusError{StatusCode: 502}, true},
+		{"rate limited", &StatusError{StatusCode: 429}, true},
+		{"not found", &StatusError{StatusCode: 404}, false},
+		{"bad request", &StatusError{StatusCode: 400}, false},
+		{"connection refused", urlError(&net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}), true},
+		{"connection reset", urlError(&net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}), true},
+		{"timeout", urlError(timeoutError{}), true},
+		{"unexpected eof", urlError(io.ErrUnexpectedEOF), true},
+		{"closed by the server", urlError(io.EOF), true},
+		{"unsupported scheme", urlError(errors.New(`unsupported protocol scheme "ftp"`)), false},
+		{"untrusted certificate", urlError(x509.UnknownAuthorityError{}), false},
+		{"unknown host", urlError(&net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true}}), false},
+		{"canceled", urlError(context.Canceled), false},
+		{"marshal error", errMarshal, false},
+		{"other error", errors.New("invalid url"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryable(tt.err); got != tt.want {
+				t.Errorf("got %v for %v, want %v", got, tt.err, tt.want)
+			}
+		})
+	}
+}
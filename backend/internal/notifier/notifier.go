@@ -0,0 +1,95 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/hyoa/wall-eve/backend/internal/namespace"
+)
+
+const (
+	EventIndexationFailed = "indexationFailed"
+	EventRegionAdded      = "regionAdded"
+	// EventAlertFired is read from the alertFired stream of the alerter
+	EventAlertFired = "alertFired"
+
+	maxEvents = 10000
+)
+
+// Event is something to push out, its data is given to the templates.
+type Event struct {
+	Id         string
+	Type       string
+	OccurredAt time.Time
+	Data       map[string]interface{}
+}
+
+// Message is an event rendered for a channel.
+type Message struct {
+	Event Event
+	Text  string
+}
+
+type Notifier interface {
+	Notify(ctx context.Context, message Message) error
+}
+
+// Publish add an event to the stream read by the dispatcher.
+func Publish(eventType string, data map[string]interface{}, client *goredis.Client) error {
+	content, errMarshal := json.Marshal(data)
+
+	if errMarshal != nil {
+		return errMarshal
+	}
+
+	return client.XAdd(context.Background(), &goredis.XAddArgs{
+		Stream: namespace.Key("notificationEvents"),
+		MaxLen: maxEvents,
+		Approx: true,
+		Values: []interface{}{"type", eventType, "occurredAt", time.Now().Unix(), "data", content},
+	}).Err()
+}
+
+// ParseEvent read an event published with Publish.
+func ParseEvent(message goredis.XMessage) (Event, error) {
+	event := Event{Id: message.ID, Data: make(map[string]interface{})}
+
+	eventType, _ := message.Values["type"].(string)
+	if eventType == "" {
+		return Event{}, fmt.Errorf("event %s has no type", message.ID)
+	}
+	event.Type = eventType
+
+	if val, ok := message.Values["occurredAt"].(string); ok {
+		ts, _ := strconv.ParseInt(val, 10, 64)
+		event.OccurredAt = time.Unix(ts, 0).UTC()
+	}
+
+	if val, ok := message.Values["data"].(string); ok {
+		if err := json.Unmarshal([]byte(val), &event.Data); err != nil {
+			return Event{}, fmt.Errorf("event %s has an invalid data: %w", message.ID, err)
+		}
+	}
+
+	return event, nil
+}
+
+// ParseAlertFired read an alert of the alertFired stream as an event.
+func ParseAlertFired(message goredis.XMessage) (Event, error) {
+	event := Event{Id: message.ID, Type: EventAlertFired, Data: make(map[string]interface{})}
+
+	val, _ := message.Values["fired"].(string)
+	if err := json.Unmarshal([]byte(val), &event.Data); err != nil {
+		return Event{}, fmt.Errorf("alert %s is invalid: %w", message.ID, err)
+	}
+
+	if firedAt, ok := event.Data["firedAt"].(string); ok {
+		event.OccurredAt, _ = time.Parse(time.RFC3339, firedAt)
+	}
+
+	return event, nil
+}
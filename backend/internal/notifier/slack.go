@@ -0,0 +1,22 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+type Slack struct {
+	url        string
+	httpClient *http.Client
+}
+
+func NewSlack(url string, httpClient *http.Client) *Slack {
+	return &Slack{url: url, httpClient: httpClient}
+}
+
+func (s *Slack) Notify(ctx context.Context, message Message) error {
+	body, _ := json.Marshal(map[string]string{"text": message.Text})
+
+	return postJSON(ctx, s.httpClient, s.url, body, nil)
+}
@@ -0,0 +1,74 @@
+package notifier
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	HeaderEvent     = "X-Walleve-Event"
+	HeaderTimestamp = "X-Walleve-Timestamp"
+	HeaderSignature = "X-Walleve-Signature"
+)
+
+// Webhook post the event as json, signed with the secret when there is one.
+type Webhook struct {
+	url        string
+	secret     string
+	httpClient *http.Client
+}
+
+type WebhookPayload struct {
+	Id         string                 `json:"id"`
+	Type       string                 `json:"type"`
+	OccurredAt time.Time              `json:"occurredAt"`
+	Message    string                 `json:"message"`
+	Data       map[string]interface{} `json:"data"`
+}
+
+func NewWebhook(url string, secret string, httpClient *http.Client) *Webhook {
+	return &Webhook{url: url, secret: secret, httpClient: httpClient}
+}
+
+func (w *Webhook) Notify(ctx context.Context, message Message) error {
+	body, errMarshal := json.Marshal(WebhookPayload{
+		Id:         message.Event.Id,
+		Type:       message.Event.Type,
+		OccurredAt: message.Event.OccurredAt,
+		Message:    message.Text,
+		Data:       message.Event.Data,
+	})
+
+	if errMarshal != nil {
+		return errMarshal
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	headers := map[string]string{
+		HeaderEvent:     message.Event.Type,
+		HeaderTimestamp: timestamp,
+	}
+
+	if w.secret != "" {
+		headers[HeaderSignature] = Sign(w.secret, timestamp, body)
+	}
+
+	return postJSON(ctx, w.httpClient, w.url, body, headers)
+}
+
+// Sign return the signature of a webhook, the receiver compute it again from the timestamp header and the raw body.
+// eg: sha256=hex(hmac-sha256(secret, "{timestamp}.{body}"))
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
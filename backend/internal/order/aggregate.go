@@ -0,0 +1,122 @@
+package order
+
+import (
+	"sync"
+	"time"
+)
+
+// Orders from players structures require an authentication to get some data.
+// Not hard to do, but out of the scope :)
+const maxNpcLocationId = 2147483647
+
+type AggregateKey struct {
+	LocationId int
+	TypeId     int
+}
+
+// Aggregate keep the best prices and the total volumes of a type at a location.
+type Aggregate struct {
+	LocationId int
+	SystemId   int
+	TypeId     int
+	BuyPrice   float64
+	SellPrice  float64
+	BuyVolume  int
+	SellVolume int
+}
+
+// Aggregates fold orders as they are decoded, so a region never has to be held in memory.
+type Aggregates struct {
+	mu           sync.Mutex
+	items        map[AggregateKey]*Aggregate
+	lastModified time.Time
+}
+
+func NewAggregates() *Aggregates {
+	return &Aggregates{
+		items: make(map[AggregateKey]*Aggregate),
+	}
+}
+
+func (a *Aggregates) Add(o Order) {
+	if o.LocationId > maxNpcLocationId {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	key := AggregateKey{LocationId: o.LocationId, TypeId: o.TypeId}
+	item, ok := a.items[key]
+
+	if !ok {
+		item = &Aggregate{LocationId: o.LocationId, SystemId: o.SystemId, TypeId: o.TypeId}
+		a.items[key] = item
+	}
+
+	if o.IsBuyOrder {
+		item.BuyVolume += o.VolumeTotal
+		if o.Price > item.BuyPrice {
+			item.BuyPrice = o.Price
+		}
+	} else {
+		item.SellVolume += o.VolumeTotal
+		if item.SellPrice == 0 || o.Price < item.SellPrice {
+			item.SellPrice = o.Price
+		}
+	}
+}
+
+// SetLastModified keep the most recent Last-Modified of the pages, it is when ESI built the snapshot.
+func (a *Aggregates) SetLastModified(t time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if t.After(a.lastModified) {
+		a.lastModified = t
+	}
+}
+
+func (a *Aggregates) LastModified() time.Time {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.lastModified
+}
+
+// Merge fold the aggregates of a page into a, other must not be used anymore.
+func (a *Aggregates) Merge(other *Aggregates) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if other.lastModified.After(a.lastModified) {
+		a.lastModified = other.lastModified
+	}
+
+	for key, o := range other.items {
+		item, ok := a.items[key]
+
+		if !ok {
+			a.items[key] = o
+			continue
+		}
+
+		item.BuyVolume += o.BuyVolume
+		item.SellVolume += o.SellVolume
+
+		if o.BuyPrice > item.BuyPrice {
+			item.BuyPrice = o.BuyPrice
+		}
+
+		if o.SellPrice != 0 && (item.SellPrice == 0 || o.SellPrice < item.SellPrice) {
+			item.SellPrice = o.SellPrice
+		}
+	}
+}
+
+func (a *Aggregates) Items() map[AggregateKey]*Aggregate {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.items
+}
@@ -1,16 +1,24 @@
 package order
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/hyoa/wall-eve/backend/internal/esi"
 	"github.com/panjf2000/ants/v2"
+	log "github.com/sirupsen/logrus"
 )
 
+const pageTimeout = 2 * time.Minute
+
 type Order struct {
 	IsBuyOrder  bool    `json:"is_buy_order"`
 	LocationId  int     `json:"location_id"`
@@ -22,9 +30,43 @@ type Order struct {
 	OrderId     int     `json:"order_id"`
 }
 
-func GetOrdersFromEsiForRegion(regionId int) []Order {
-	headUrl := fmt.Sprintf("https://esi.evetech.net/latest/markets/%d/orders/?datasource=tranquility&order_type=all&page=1", regionId)
-	nbPages := getNbPages(headUrl)
+type PageError struct {
+	Page int
+	Err  error
+}
+
+func (e *PageError) Error() string {
+	return fmt.Sprintf("page %d: %s", e.Page, e.Err.Error())
+}
+
+func (e *PageError) Unwrap() error {
+	return e.Err
+}
+
+// FetchError is returned when a region could not be fetched completely.
+type FetchError struct {
+	RegionId int
+	NbPages  int
+	Pages    []*PageError
+}
+
+func (e *FetchError) Error() string {
+	pages := make([]string, 0)
+	for _, p := range e.Pages {
+		pages = append(pages, p.Error())
+	}
+
+	return fmt.Sprintf("unable to fetch %d/%d pages for region %d: %s", len(e.Pages), e.NbPages, e.RegionId, strings.Join(pages, ", "))
+}
+
+// AggregateOrdersFromEsiForRegion stream every page of the region and fold its orders by location and type.
+func AggregateOrdersFromEsiForRegion(ctx context.Context, regionId int, esiClient *esi.Client) (*Aggregates, error) {
+	aggregates := NewAggregates()
+	nbPages, errPages := getNbPages(ctx, regionId, esiClient)
+
+	if errPages != nil {
+		return aggregates, fmt.Errorf("unable to get pages for region %d: %w", regionId, errPages)
+	}
 
 	pool, _ := ants.NewPoolWithFunc(20, taskGetOrderForPageHandler)
 	defer pool.Release()
@@ -35,9 +77,12 @@ func GetOrdersFromEsiForRegion(regionId int) []Order {
 	for p := 1; p <= nbPages; p++ {
 		wg.Add(1)
 		task := &taskGetOrderForPagePayload{
-			wg:       &wg,
-			page:     p,
-			regionId: regionId,
+			ctx:        ctx,
+			wg:         &wg,
+			page:       p,
+			regionId:   regionId,
+			esiClient:  esiClient,
+			aggregates: aggregates,
 		}
 
 		tasks = append(tasks, task)
@@ -46,16 +91,19 @@ func GetOrdersFromEsiForRegion(regionId int) []Order {
 
 	wg.Wait()
 
-	orders := make([]Order, 0)
+	fetchErr := &FetchError{RegionId: regionId, NbPages: nbPages, Pages: make([]*PageError, 0)}
 	for _, task := range tasks {
-		if task.err {
-			continue
+		if task.err != nil {
+			log.Warnf("Unable to fetch page %d for region %d: %s", task.page, regionId, task.err.Error())
+			fetchErr.Pages = append(fetchErr.Pages, &PageError{Page: task.page, Err: task.err})
 		}
+	}
 
-		orders = append(orders, task.orders...)
+	if len(fetchErr.Pages) > 0 {
+		return aggregates, fetchErr
 	}
 
-	return orders
+	return aggregates, nil
 }
 
 func taskGetOrderForPageHandler(data interface{}) {
@@ -64,45 +112,101 @@ func taskGetOrderForPageHandler(data interface{}) {
 }
 
 type taskGetOrderForPagePayload struct {
-	wg       *sync.WaitGroup
-	page     int
-	orders   []Order
-	regionId int
-	err      bool
+	ctx        context.Context
+	wg         *sync.WaitGroup
+	page       int
+	regionId   int
+	esiClient  *esi.Client
+	aggregates *Aggregates
+	err        error
 }
 
 func (t *taskGetOrderForPagePayload) fetchPage() {
-	u := fmt.Sprintf("https://esi.evetech.net/latest/markets/%d/orders/?datasource=tranquility&order_type=all&page=%d", t.regionId, t.page)
+	defer t.wg.Done()
+
+	ctx, cancel := context.WithTimeout(t.ctx, pageTimeout)
+	defer cancel()
+
+	// A retried page start from scratch, its orders are merged only once completely decoded
+	var pageAggregates *Aggregates
+	errGet := t.esiClient.Fetch(ctx, http.MethodGet, ordersPath(t.regionId), ordersQuery(t.page), func(resp *http.Response) error {
+		pageAggregates = NewAggregates()
+
+		if lastModified, errParse := http.ParseTime(resp.Header.Get("Last-Modified")); errParse == nil {
+			pageAggregates.SetLastModified(lastModified)
+		}
 
-	resp, errGet := http.Get(u)
+		return decodeOrders(resp.Body, pageAggregates.Add)
+	})
 
 	if errGet != nil {
-		t.err = true
-		t.wg.Done()
+		t.err = errGet
+		return
 	}
 
-	b, errBody := ioutil.ReadAll(resp.Body)
+	t.aggregates.Merge(pageAggregates)
+}
+
+// decodeOrders read the orders of a page one by one.
+func decodeOrders(r io.Reader, fn func(o Order)) error {
+	dec := json.NewDecoder(r)
+
+	token, errToken := dec.Token()
+
+	if errToken != nil {
+		return unexpectedEOF(errToken)
+	}
 
-	if errBody != nil {
-		t.err = true
-		t.wg.Done()
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("expected an array of orders, got %v", token)
 	}
 
-	var orders []Order
-	json.Unmarshal(b, &orders)
-	t.orders = orders
+	for dec.More() {
+		var o Order
+		if errDecode := dec.Decode(&o); errDecode != nil {
+			return unexpectedEOF(errDecode)
+		}
+
+		fn(o)
+	}
+
+	_, errToken = dec.Token()
+
+	return unexpectedEOF(errToken)
+}
+
+// unexpectedEOF make a body cut between two orders look like any other truncated body.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
 
-	t.wg.Done()
+	return err
 }
 
-func getNbPages(url string) int {
-	resp, err := http.Head(url)
+func getNbPages(ctx context.Context, regionId int, esiClient *esi.Client) (int, error) {
+	header, err := esiClient.Head(ctx, ordersPath(regionId), ordersQuery(1))
 
 	if err != nil {
-		return 0
+		return 0, err
+	}
+
+	nbPages, errParse := strconv.ParseInt(header.Get("X-Pages"), 10, 32)
+
+	if errParse != nil {
+		return 0, fmt.Errorf("invalid X-Pages header: %w", errParse)
 	}
 
-	nbPages, _ := strconv.ParseInt(resp.Header.Get("X-Pages"), 10, 32)
+	return int(nbPages), nil
+}
 
-	return int(nbPages)
+func ordersPath(regionId int) string {
+	return fmt.Sprintf("markets/%d/orders", regionId)
+}
+
+func ordersQuery(page int) url.Values {
+	return url.Values{
+		"order_type": []string{"all"},
+		"page":       []string{strconv.Itoa(page)},
+	}
 }
@@ -0,0 +1,302 @@
+package querycache
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/hyoa/wall-eve/backend/internal/denormorder"
+	"github.com/hyoa/wall-eve/backend/internal/indexation"
+	"github.com/hyoa/wall-eve/backend/internal/namespace"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// Ttl is a safety net, the entries are invalidated when their regions are indexed again
+	Ttl = 5 * time.Minute
+	// A bigger page is not worth the memory, it is rarely asked twice
+	maxCachedOrders = 1000
+	lockTtl         = 10 * time.Second
+	lockPollDelay   = 50 * time.Millisecond
+	// anyRegion hold the entries that may change with the indexation of any region, eg: a search without result
+	anyRegion = "any"
+)
+
+// storeScript only store the entry if no region has been invalidated since the search started,
+// or the entry could hold the items from before the indexation.
+var storeScript = goredis.NewScript(`
+if tonumber(redis.call('GET', KEYS[1]) or '0') ~= tonumber(ARGV[1]) then
+	return 0
+end
+
+redis.call('SET', KEYS[2], ARGV[2], 'EX', ARGV[3])
+for i = 3, #KEYS do
+	redis.call('SADD', KEYS[i], KEYS[2])
+	redis.call('EXPIRE', KEYS[i], ARGV[3])
+end
+
+return 1
+`)
+
+var invalidateScript = goredis.NewScript(`
+redis.call('INCR', KEYS[1])
+for i = 2, #KEYS do
+	for _, entry in ipairs(redis.call('SMEMBERS', KEYS[i])) do
+		redis.call('DEL', entry)
+	end
+	redis.call('DEL', KEYS[i])
+end
+
+return 1
+`)
+
+type call struct {
+	wg     sync.WaitGroup
+	result denormorder.SearchResult
+	hit    bool
+	err    error
+}
+
+// Cache keep the results of the searches by their normalized filter. A search missing from the cache
+// is only run once at a time, the concurrent requests wait for its result.
+type Cache struct {
+	client *goredis.Client
+	mu     sync.Mutex
+	calls  map[string]*call
+}
+
+func NewCache(client *goredis.Client) *Cache {
+	return &Cache{
+		client: client,
+		calls:  make(map[string]*call),
+	}
+}
+
+// Lookup return the result of the filter when it is in the cache, without searching it.
+func (c *Cache) Lookup(filter denormorder.Filter) (denormorder.SearchResult, bool) {
+	return c.get(filter.CacheKey())
+}
+
+// Search return the result of the filter and tell if it has been read from the cache.
+func (c *Cache) Search(filter denormorder.Filter) (denormorder.SearchResult, bool, error) {
+	key := filter.CacheKey()
+
+	if result, ok := c.get(key); ok {
+		return result, true, nil
+	}
+
+	c.mu.Lock()
+	if running, ok := c.calls[key]; ok {
+		c.mu.Unlock()
+		running.wg.Wait()
+
+		// The follower get the same answer than the leader, it did not read the cache itself
+		return running.result, running.hit, running.err
+	}
+
+	current := &call{}
+	current.wg.Add(1)
+	c.calls[key] = current
+	c.mu.Unlock()
+
+	current.result, current.hit, current.err = c.load(key, filter)
+	current.wg.Done()
+
+	c.mu.Lock()
+	delete(c.calls, key)
+	c.mu.Unlock()
+
+	return current.result, current.hit, current.err
+}
+
+func (c *Cache) load(key string, filter denormorder.Filter) (denormorder.SearchResult, bool, error) {
+	lockKey := namespace.Key("marketCacheLock", key)
+	locked, errLock := c.client.SetNX(context.Background(), lockKey, 1, lockTtl).Result()
+
+	// Another api is running the search, wait for its result rather than running it again
+	if errLock == nil && !locked {
+		for deadline := time.Now().Add(lockTtl); time.Now().Before(deadline); {
+			time.Sleep(lockPollDelay)
+
+			if result, ok := c.get(key); ok {
+				return result, true, nil
+			}
+
+			if exists, _ := c.client.Exists(context.Background(), lockKey).Result(); exists == 0 {
+				break
+			}
+		}
+	}
+
+	if locked {
+		defer c.client.Del(context.Background(), lockKey)
+	}
+
+	generation, _ := c.client.Get(context.Background(), namespace.Key("marketCacheGeneration")).Int64()
+	result, errSearch := denormorder.GetDenormalizedOrdersWithFilter(filter, c.client)
+
+	if errSearch != nil {
+		return result, false, errSearch
+	}
+
+	// The entry depend on every region of the location, not only on the regions of the items of the page:
+	// an item can leave the page or enter it with the indexation of any of them
+	regions, errRegions := denormorder.RegionsOfLocation(filter.Location, c.client)
+
+	if errRegions != nil {
+		log.Errorln(errRegions)
+		return result, false, nil
+	}
+
+	lastModified, errLastModified := RegionsIndexedAt(regions, c.client)
+
+	if errLastModified != nil {
+		log.Errorln(errLastModified)
+		return result, false, nil
+	}
+	result.LastModified = lastModified
+
+	if len(result.Orders) <= maxCachedOrders {
+		if errStore := c.store(key, generation, filter, regions, result); errStore != nil {
+			log.Errorln(errStore)
+		}
+	}
+
+	return result, false, nil
+}
+
+// LastModified return the most recent indexation of the regions of the location, or a zero time when it
+// can not be read.
+func (c *Cache) LastModified(location string) time.Time {
+	regions, errRegions := denormorder.RegionsOfLocation(location, c.client)
+
+	if errRegions != nil {
+		log.Errorln(errRegions)
+		return time.Time{}
+	}
+
+	lastModified, errLastModified := RegionsIndexedAt(regions, c.client)
+
+	if errLastModified != nil {
+		log.Errorln(errLastModified)
+		return time.Time{}
+	}
+
+	return lastModified
+}
+
+// RegionsIndexedAt return the most recent indexation of the regions, it is the Last-Modified of a list.
+// The items of a page can not tell it: an indexation also remove items from the list.
+func RegionsIndexedAt(regions []int, client *goredis.Client) (time.Time, error) {
+	var lastModified time.Time
+
+	for _, regionId := range regions {
+		regionIndexation, err := indexation.GetRegionIndexation(regionId, client)
+
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		if regionIndexation.IndexedAt.After(lastModified) {
+			lastModified = regionIndexation.IndexedAt
+		}
+	}
+
+	return lastModified, nil
+}
+
+func (c *Cache) get(key string) (denormorder.SearchResult, bool) {
+	content, err := c.client.Get(context.Background(), namespace.Key("marketCache", key)).Bytes()
+
+	if err != nil {
+		if err != goredis.Nil {
+			log.Errorln(err)
+		}
+		return denormorder.SearchResult{}, false
+	}
+
+	var result denormorder.SearchResult
+	if errUnmarshal := json.Unmarshal(content, &result); errUnmarshal != nil {
+		return denormorder.SearchResult{}, false
+	}
+
+	return result, true
+}
+
+func (c *Cache) store(key string, generation int64, filter denormorder.Filter, locationRegions []int, result denormorder.SearchResult) error {
+	content, errMarshal := json.Marshal(result)
+
+	if errMarshal != nil {
+		return errMarshal
+	}
+
+	keys := []string{namespace.Key("marketCacheGeneration"), namespace.Key("marketCache", key)}
+
+	regions := make(map[int]bool)
+	for _, regionId := range locationRegions {
+		regions[regionId] = true
+	}
+	for _, o := range result.Orders {
+		regions[o.RegionId] = true
+	}
+
+	for regionId := range regions {
+		keys = append(keys, namespace.Key("marketCacheRegion", regionId))
+	}
+
+	// A location that is not indexed yet, or no location, can be found in the next region indexed
+	if len(regions) == 0 || filter.Location == "" {
+		keys = append(keys, namespace.Key("marketCacheRegion", anyRegion))
+	}
+
+	return storeScript.Run(context.Background(), c.client, keys, generation, content, int(Ttl.Seconds())).Err()
+}
+
+// Invalidate remove the entries holding items of the region.
+func Invalidate(regionId int, client *goredis.Client) error {
+	keys := []string{
+		namespace.Key("marketCacheGeneration"),
+		namespace.Key("marketCacheRegion", regionId),
+		namespace.Key("marketCacheRegion", anyRegion),
+	}
+
+	return invalidateScript.Run(context.Background(), client, keys).Err()
+}
+
+// WatchIndexations invalidate the entries of each region indexed, from the start of the api.
+func (c *Cache) WatchIndexations() {
+	lastId := "0"
+	if last, _ := c.client.XRevRangeN(context.Background(), namespace.Key("indexationFinished"), "+", "-", 1).Result(); len(last) > 0 {
+		lastId = last[0].ID
+	}
+
+	for {
+		xReadArgs := goredis.XReadArgs{
+			Streams: []string{namespace.Key("indexationFinished"), lastId},
+			Count:   10,
+			Block:   2 * time.Second,
+		}
+		res, _ := c.client.XRead(context.Background(), &xReadArgs).Result()
+
+		for _, stream := range res {
+			for _, message := range stream.Messages {
+				lastId = message.ID
+
+				val, _ := message.Values["regionId"].(string)
+				regionId, _ := strconv.Atoi(val)
+
+				// A failed indexation keep the previous snapshot of the region
+				if status, _ := message.Values["status"].(string); regionId == 0 || status == indexation.StatusFailed {
+					continue
+				}
+
+				if errInvalidate := Invalidate(regionId, c.client); errInvalidate != nil {
+					log.Errorln(errInvalidate)
+				}
+			}
+		}
+	}
+}
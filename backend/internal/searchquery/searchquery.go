@@ -0,0 +1,199 @@
+// Package searchquery build the queries of RediSearch, the values given by the users are escaped so they
+// can never change the meaning of a query.
+package searchquery
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// separators are the characters splitting the terms of a text field, with the spaces, in the default tokenizer.
+const separators = ",.<>{}[]\"':;!@#$%^&*()-+=~"
+
+// Clause is a part of a query, the zero value match every document.
+type Clause struct {
+	query string
+	// operator joining the parts of the clause, empty for a single condition
+	operator string
+}
+
+func (c Clause) String() string {
+	if c.query == "" {
+		return "*"
+	}
+
+	return c.query
+}
+
+func (c Clause) IsEmpty() bool {
+	return c.query == ""
+}
+
+// All match every document.
+func All() Clause {
+	return Clause{}
+}
+
+// Term is a term of a text search.
+type Term struct {
+	value  string
+	suffix string
+	prefix string
+}
+
+// Word match the term exactly, after the stemming of the index.
+func Word(value string) Term {
+	return Term{value: value}
+}
+
+// Prefix match the words starting with the term.
+func Prefix(value string) Term {
+	return Term{value: value, suffix: "*"}
+}
+
+// Fuzzy match the words at a Levenshtein distance of 1 from the term.
+func Fuzzy(value string) Term {
+	return Term{value: value, prefix: "%", suffix: "%"}
+}
+
+// Terms split a text like the default tokenizer of the index, the terms are lowercased.
+func Terms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune(separators, r)
+	})
+}
+
+// Text match the documents having every term in the text field, an empty term is ignored.
+// eg: Text("typeName", Prefix("trit")) => @typeName:(trit*)
+func Text(field string, terms ...Term) Clause {
+	parts := make([]string, 0, len(terms))
+	for _, term := range terms {
+		if term.value == "" {
+			continue
+		}
+		parts = append(parts, term.prefix+Escape(term.value)+term.suffix)
+	}
+
+	if len(parts) == 0 {
+		return Clause{}
+	}
+
+	return Clause{query: fmt.Sprintf("@%s:(%s)", field, strings.Join(parts, " "))}
+}
+
+// Tag match the documents having one of the values in the tag field.
+// eg: Tag("locationIdTags", "60003760") => @locationIdTags:{60003760}
+func Tag(field string, values ...string) Clause {
+	parts := make([]string, 0, len(values))
+	for _, value := range values {
+		parts = append(parts, Escape(value))
+	}
+
+	if len(parts) == 0 {
+		return Clause{}
+	}
+
+	return Clause{query: fmt.Sprintf("@%s:{%s}", field, strings.Join(parts, " | "))}
+}
+
+// Bound of a numeric range, an infinite value is unbounded.
+type Bound struct {
+	Value     float64
+	Exclusive bool
+}
+
+func Inclusive(v float64) Bound {
+	return Bound{Value: v}
+}
+
+func Exclusive(v float64) Bound {
+	return Bound{Value: v, Exclusive: true}
+}
+
+func (b Bound) String() string {
+	value := strconv.FormatFloat(b.Value, 'f', -1, 64)
+
+	if math.IsInf(b.Value, -1) {
+		value = "-inf"
+	} else if math.IsInf(b.Value, 1) {
+		value = "+inf"
+	}
+
+	if b.Exclusive {
+		return "(" + value
+	}
+
+	return value
+}
+
+// Range match the documents with the numeric field between min and max.
+// eg: Range("sellPrice", Inclusive(0), Exclusive(5)) => @sellPrice:[0 (5]
+func Range(field string, min Bound, max Bound) Clause {
+	return Clause{query: fmt.Sprintf("@%s:[%s %s]", field, min, max)}
+}
+
+// Equal match the documents with the numeric field equal to v.
+func Equal(field string, v int) Clause {
+	return Range(field, Inclusive(float64(v)), Inclusive(float64(v)))
+}
+
+// And match the documents matching every clause, the empty ones are ignored.
+func And(clauses ...Clause) Clause {
+	return combine(" ", clauses)
+}
+
+// Or match the documents matching at least one clause, an empty clause match every document.
+func Or(clauses ...Clause) Clause {
+	for _, c := range clauses {
+		if c.IsEmpty() {
+			return Clause{}
+		}
+	}
+
+	return combine("|", clauses)
+}
+
+// combine join the clauses with the operator, a clause joined with another operator is put in parenthesis.
+func combine(operator string, clauses []Clause) Clause {
+	nonEmpty := make([]Clause, 0, len(clauses))
+	for _, c := range clauses {
+		if !c.IsEmpty() {
+			nonEmpty = append(nonEmpty, c)
+		}
+	}
+
+	if len(nonEmpty) == 0 {
+		return Clause{}
+	}
+
+	if len(nonEmpty) == 1 {
+		return nonEmpty[0]
+	}
+
+	parts := make([]string, 0, len(nonEmpty))
+	for _, c := range nonEmpty {
+		if c.operator == "" || c.operator == operator {
+			parts = append(parts, c.query)
+		} else {
+			parts = append(parts, "("+c.query+")")
+		}
+	}
+
+	return Clause{query: strings.Join(parts, operator), operator: operator}
+}
+
+// Escape prefix every character that is not a letter or a digit with a backslash, so it is part of the value.
+func Escape(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,213 @@
+package universe
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/hyoa/wall-eve/backend/internal/denormorder"
+	"github.com/hyoa/wall-eve/backend/internal/extradata"
+	"github.com/hyoa/wall-eve/backend/internal/indexation"
+	"github.com/hyoa/wall-eve/backend/internal/namespace"
+)
+
+var ErrNotFound = errors.New("not found")
+
+type Region struct {
+	RegionId      int        `json:"regionId"`
+	RegionName    string     `json:"regionName"`
+	DocumentCount int        `json:"documentCount"`
+	IndexedAt     *time.Time `json:"indexedAt"`
+}
+
+type System struct {
+	SystemId      int        `json:"systemId"`
+	SystemName    string     `json:"systemName"`
+	RegionId      int        `json:"regionId"`
+	DocumentCount int        `json:"documentCount"`
+	IndexedAt     *time.Time `json:"indexedAt"`
+}
+
+type Location struct {
+	LocationId    int        `json:"locationId"`
+	LocationName  string     `json:"locationName"`
+	SystemId      int        `json:"systemId"`
+	RegionId      int        `json:"regionId"`
+	DocumentCount int        `json:"documentCount"`
+	IndexedAt     *time.Time `json:"indexedAt"`
+}
+
+// GetRegions return the regions known by the scheduler sorted by name.
+func GetRegions(client *goredis.Client) ([]Region, error) {
+	members, errMembers := client.SMembers(context.Background(), namespace.Key("validRegions")).Result()
+
+	if errMembers != nil {
+		return nil, errMembers
+	}
+
+	counts, errCounts := denormorder.CountByRegion(client)
+
+	if errCounts != nil {
+		return nil, errCounts
+	}
+
+	countByRegion := make(map[int]int)
+	for _, group := range counts {
+		countByRegion[group.Values["regionId"]] = group.Count
+	}
+
+	regionIds := make([]int, 0)
+	for _, member := range members {
+		if regionId, err := strconv.Atoi(member); err == nil {
+			regionIds = append(regionIds, regionId)
+		}
+	}
+
+	names, _ := extradata.GetCachedNames("regions", regionIds, client)
+
+	regions := make([]Region, 0)
+	for _, regionId := range regionIds {
+		regions = append(regions, Region{
+			RegionId:      regionId,
+			RegionName:    names[regionId],
+			DocumentCount: countByRegion[regionId],
+			IndexedAt:     indexedAt(regionId, client),
+		})
+	}
+
+	sort.Slice(regions, func(i, j int) bool { return regions[i].RegionName < regions[j].RegionName })
+
+	return regions, nil
+}
+
+// GetRegion return ErrNotFound if the region is not known by the scheduler.
+func GetRegion(regionId int, client *goredis.Client) (Region, error) {
+	regions, err := GetRegions(client)
+
+	if err != nil {
+		return Region{}, err
+	}
+
+	for _, region := range regions {
+		if region.RegionId == regionId {
+			return region, nil
+		}
+	}
+
+	return Region{}, ErrNotFound
+}
+
+// GetSystemsInRegion return the systems having market data sorted by name, ErrNotFound if the region is unknown.
+func GetSystemsInRegion(regionId int, client *goredis.Client) ([]System, error) {
+	isValid, _ := client.SIsMember(context.Background(), namespace.Key("validRegions"), regionId).Result()
+
+	if !isValid {
+		return nil, ErrNotFound
+	}
+
+	counts, errCounts := denormorder.CountBySystemInRegion(regionId, client)
+
+	if errCounts != nil {
+		return nil, errCounts
+	}
+
+	systemIds := make([]int, 0)
+	for _, group := range counts {
+		systemIds = append(systemIds, group.Values["systemId"])
+	}
+
+	names, _ := extradata.GetCachedNames("systems", systemIds, client)
+	regionIndexedAt := indexedAt(regionId, client)
+
+	systems := make([]System, 0)
+	for _, group := range counts {
+		systems = append(systems, System{
+			SystemId:      group.Values["systemId"],
+			SystemName:    names[group.Values["systemId"]],
+			RegionId:      regionId,
+			DocumentCount: group.Count,
+			IndexedAt:     regionIndexedAt,
+		})
+	}
+
+	sort.Slice(systems, func(i, j int) bool { return systems[i].SystemName < systems[j].SystemName })
+
+	return systems, nil
+}
+
+// GetLocationsInSystem return the locations having market data sorted by name, ErrNotFound if there is none.
+func GetLocationsInSystem(systemId int, client *goredis.Client) ([]Location, error) {
+	counts, errCounts := denormorder.CountByLocationInSystem(systemId, client)
+
+	if errCounts != nil {
+		return nil, errCounts
+	}
+
+	if len(counts) == 0 {
+		return nil, ErrNotFound
+	}
+
+	locationIds := make([]int, 0)
+	for _, group := range counts {
+		locationIds = append(locationIds, group.Values["locationId"])
+	}
+
+	names, _ := extradata.GetCachedNames("stations", locationIds, client)
+	// A system belong to a single region
+	regionId := counts[0].Values["regionId"]
+	regionIndexedAt := indexedAt(regionId, client)
+
+	locations := make([]Location, 0)
+	for _, group := range counts {
+		locations = append(locations, Location{
+			LocationId:    group.Values["locationId"],
+			LocationName:  names[group.Values["locationId"]],
+			SystemId:      systemId,
+			RegionId:      regionId,
+			DocumentCount: group.Count,
+			IndexedAt:     regionIndexedAt,
+		})
+	}
+
+	sort.Slice(locations, func(i, j int) bool { return locations[i].LocationName < locations[j].LocationName })
+
+	return locations, nil
+}
+
+// GetSystem return ErrNotFound if there is no market data in the system.
+func GetSystem(systemId int, client *goredis.Client) (System, error) {
+	locations, err := GetLocationsInSystem(systemId, client)
+
+	if err != nil {
+		return System{}, err
+	}
+
+	names, _ := extradata.GetCachedNames("systems", []int{systemId}, client)
+
+	system := System{
+		SystemId:   systemId,
+		SystemName: names[systemId],
+		RegionId:   locations[0].RegionId,
+		IndexedAt:  locations[0].IndexedAt,
+	}
+
+	for _, location := range locations {
+		system.DocumentCount += location.DocumentCount
+	}
+
+	return system, nil
+}
+
+// indexedAt is nil when the region has never been indexed.
+func indexedAt(regionId int, client *goredis.Client) *time.Time {
+	regionIndexation, err := indexation.GetRegionIndexation(regionId, client)
+
+	if err != nil || regionIndexation.IndexedAt.IsZero() {
+		return nil
+	}
+
+	return &regionIndexation.IndexedAt
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	goredis "github.com/go-redis/redis/v8"
+	"github.com/hyoa/wall-eve/backend/internal/namespace"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -21,7 +22,7 @@ func Create(client *goredis.Client) refresh {
 }
 
 func (r *refresh) Run() {
-	pubsub := r.client.Subscribe(context.Background(), "apiEvent")
+	pubsub := r.client.Subscribe(context.Background(), namespace.Key("apiEvent"))
 
 	defer pubsub.Close()
 
@@ -38,7 +39,7 @@ func (r *refresh) Run() {
 }
 
 func (r *refresh) catchupRegionIfNeeded(regionId int) error {
-	key := fmt.Sprintf("indexationCatchupLaunch:%d", regionId)
+	key := namespace.Key("indexationCatchupLaunch", regionId)
 	v, err := r.client.Get(context.Background(), key).Result()
 
 	if (err != nil && err.Error() != "redis: nil") || v == "1" {
@@ -48,7 +49,7 @@ func (r *refresh) catchupRegionIfNeeded(regionId int) error {
 	now := time.Now().Unix()
 	res, _ := r.client.ZRangeByScore(
 		context.Background(),
-		"indexationDelayed",
+		namespace.Key("indexationDelayed"),
 		&goredis.ZRangeBy{
 			Min: fmt.Sprintf("%d", now),
 			Max: fmt.Sprintf("%d", now+600),
@@ -57,7 +58,7 @@ func (r *refresh) catchupRegionIfNeeded(regionId int) error {
 
 	if len(res) == 0 {
 		args := goredis.XAddArgs{
-			Stream: "indexationCatchup",
+			Stream: namespace.Key("indexationCatchup"),
 			Values: []interface{}{"regionId", regionId},
 		}
 
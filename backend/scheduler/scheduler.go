@@ -2,28 +2,36 @@ package scheduler
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"strconv"
 	"time"
 
 	goredis "github.com/go-redis/redis/v8"
+	"github.com/hyoa/wall-eve/backend/internal/esi"
 	"github.com/hyoa/wall-eve/backend/internal/extradata"
+	"github.com/hyoa/wall-eve/backend/internal/namespace"
+	"github.com/hyoa/wall-eve/backend/internal/notifier"
 	log "github.com/sirupsen/logrus"
 )
 
+// validationRetryDelay is the wait before validating again a region when ESI could not answer.
+const validationRetryDelay = 5 * time.Minute
+
 type Scheduler struct {
-	client *goredis.Client
+	client    *goredis.Client
+	esiClient *esi.Client
 }
 
-func Create(client *goredis.Client) Scheduler {
+func Create(client *goredis.Client, esiClient *esi.Client) Scheduler {
 	return Scheduler{
-		client: client,
+		client:    client,
+		esiClient: esiClient,
 	}
 }
 
 func (s *Scheduler) RunScheduleIndexation(callback func()) {
-	indexationFinishedlastIdChecked, _ := s.client.Get(context.Background(), "scheduler:indexationFinishedLastId").Result()
-	indexationCatchuplastIdChecked, _ := s.client.Get(context.Background(), "scheduler:indexationCatchupLastId").Result()
+	indexationFinishedlastIdChecked, _ := s.client.Get(context.Background(), namespace.Key("scheduler:indexationFinishedLastId")).Result()
+	indexationCatchuplastIdChecked, _ := s.client.Get(context.Background(), namespace.Key("scheduler:indexationCatchupLastId")).Result()
 
 	if indexationFinishedlastIdChecked == "" {
 		indexationFinishedlastIdChecked = "0"
@@ -36,7 +44,7 @@ func (s *Scheduler) RunScheduleIndexation(callback func()) {
 	log.Infoln("Listen stream for finished indexation")
 	for {
 		xReadArgs := goredis.XReadArgs{
-			Streams: []string{"indexationFinished", "indexationCatchup", indexationFinishedlastIdChecked, indexationCatchuplastIdChecked},
+			Streams: []string{namespace.Key("indexationFinished"), namespace.Key("indexationCatchup"), indexationFinishedlastIdChecked, indexationCatchuplastIdChecked},
 			Count:   1,
 			Block:   2 * time.Second,
 		}
@@ -58,17 +66,17 @@ func (s *Scheduler) RunScheduleIndexation(callback func()) {
 			}
 
 			switch stream.Stream {
-			case "indexationFinished":
+			case namespace.Key("indexationFinished"):
 				log.Infof("Schedule region %d", regionId)
 				s.scheduleOrdersScanForRegion(regionId)
 				indexationFinishedlastIdChecked = stream.Messages[0].ID
-				s.client.Set(context.Background(), "scheduler:indexationFinishedLastId", indexationFinishedlastIdChecked, 0)
+				s.client.Set(context.Background(), namespace.Key("scheduler:indexationFinishedLastId"), indexationFinishedlastIdChecked, 0)
 				break
-			case "indexationCatchup":
+			case namespace.Key("indexationCatchup"):
 				log.Infof("Catchup region %d", regionId)
 				s.scheduleOrdersCatchupForRegion(regionId)
 				indexationCatchuplastIdChecked = stream.Messages[0].ID
-				s.client.Set(context.Background(), "scheduler:indexationCatchupLastId", indexationCatchuplastIdChecked, 0)
+				s.client.Set(context.Background(), namespace.Key("scheduler:indexationCatchupLastId"), indexationCatchuplastIdChecked, 0)
 				break
 			}
 
@@ -79,21 +87,40 @@ func (s *Scheduler) RunScheduleIndexation(callback func()) {
 }
 
 func (s *Scheduler) scheduleOrdersScanForRegion(regionId int) error {
-	if regionId == 0 || !doesRegionExist(regionId, s.client) {
+	if regionId == 0 {
+		log.Errorln("Invalid region")
+		return nil
+	}
+
+	if !s.esiClient.IsAvailable(context.Background()) {
+		s.holdUntilEndOfDowntime(regionId)
+		return nil
+	}
+
+	if exists, errValidate := doesRegionExist(regionId, s.client, s.esiClient); errValidate != nil {
+		s.holdForValidation(regionId, errValidate)
+		return nil
+	} else if !exists {
 		log.Errorln("Invalid region")
 		return nil
 	}
 
+	now := s.esiClient.Now()
+
 	delay := 3600
-	if isRegionSearchDuringInterval(regionId, int(time.Now().Add(-5*time.Minute).UnixMilli()), 300000, s.client) {
+	if isRegionSearchDuringInterval(regionId, int(now.Add(-5*time.Minute).UnixMilli()), 300000, s.client) {
 		delay = 300
-	} else if isRegionSearchDuringInterval(regionId, int(time.Now().Add(-1*time.Hour).UnixMilli()), 3600000, s.client) {
+	} else if isRegionSearchDuringInterval(regionId, int(now.Add(-1*time.Hour).UnixMilli()), 3600000, s.client) {
 		delay = 600
 	}
 
-	delayedTime := int(time.Now().Unix()) + delay
+	delayedTime := int(now.Unix()) + delay
+
+	if at := time.Unix(int64(delayedTime), 0); esi.InDowntimeWindow(at) {
+		delayedTime = int(esi.DowntimeEnd(at).Unix())
+	}
 
-	s.client.ZAdd(context.Background(), "indexationDelayed", &goredis.Z{Score: float64(delayedTime), Member: regionId})
+	s.client.ZAdd(context.Background(), namespace.Key("indexationDelayed"), &goredis.Z{Score: float64(delayedTime), Member: regionId})
 
 	return nil
 }
@@ -101,7 +128,7 @@ func (s *Scheduler) scheduleOrdersScanForRegion(regionId int) error {
 func isRegionSearchDuringInterval(regionId int, timeStart int, timeWindow int, client *goredis.Client) bool {
 	res, _ := client.Do(
 		context.Background(),
-		"TS.RANGE", fmt.Sprintf("regionFetchHistory:%d", regionId), timeStart, "+", "AGGREGATION", "sum", timeWindow,
+		"TS.RANGE", namespace.Key("regionFetchHistory", regionId), timeStart, "+", "AGGREGATION", "sum", timeWindow,
 	).Result()
 
 	count := 0
@@ -125,13 +152,26 @@ func isRegionSearchDuringInterval(regionId int, timeStart int, timeWindow int, c
 }
 
 func (s *Scheduler) scheduleOrdersCatchupForRegion(regionId int) error {
-	if regionId == 0 || !doesRegionExist(regionId, s.client) {
+	if regionId == 0 {
+		log.Errorln("Invalid region")
+		return nil
+	}
+
+	if !s.esiClient.IsAvailable(context.Background()) {
+		s.holdUntilEndOfDowntime(regionId)
+		return nil
+	}
+
+	if exists, errValidate := doesRegionExist(regionId, s.client, s.esiClient); errValidate != nil {
+		s.holdForValidation(regionId, errValidate)
+		return nil
+	} else if !exists {
 		log.Errorln("Invalid region")
 		return nil
 	}
 
 	xAddArgs := goredis.XAddArgs{
-		Stream: "indexationAdd",
+		Stream: namespace.Key("indexationAdd"),
 		Values: []interface{}{"regionId", regionId},
 	}
 	s.client.XAdd(context.Background(), &xAddArgs)
@@ -139,29 +179,69 @@ func (s *Scheduler) scheduleOrdersCatchupForRegion(regionId int) error {
 	return nil
 }
 
-func doesRegionExist(regionId int, client *goredis.Client) bool {
-	valValid, _ := client.SIsMember(context.Background(), "validRegions", regionId).Result()
+// holdUntilEndOfDowntime queue the region for when ESI is back. ESI can not validate a new region during
+// the downtime, so a known invalid region is dropped and the delayer validate the unknown ones on release.
+func (s *Scheduler) holdUntilEndOfDowntime(regionId int) {
+	if isInvalid, _ := s.client.SIsMember(context.Background(), namespace.Key("invalidRegions"), regionId).Result(); isInvalid {
+		log.Errorln("Invalid region")
+		return
+	}
+
+	resumeAt := s.esiClient.ResumeAt(s.esiClient.Now())
+	log.Infof("ESI is in downtime, hold region %d until %s", regionId, resumeAt.Format(time.RFC3339))
+
+	s.client.ZAdd(context.Background(), namespace.Key("indexationDelayed"), &goredis.Z{Score: float64(resumeAt.Unix()), Member: regionId})
+}
+
+// holdForValidation queue again a region that ESI could not validate, eg: during an outage, it come back
+// to the scheduler through indexationCatchup as long as it is not a valid region.
+func (s *Scheduler) holdForValidation(regionId int, err error) {
+	retryAt := s.esiClient.Now().Add(validationRetryDelay)
+	log.Errorf("Unable to validate region %d, retry at %s: %s", regionId, retryAt.Format(time.RFC3339), err.Error())
+
+	s.client.ZAdd(context.Background(), namespace.Key("indexationDelayed"), &goredis.Z{Score: float64(retryAt.Unix()), Member: regionId})
+}
+
+// doesRegionExist return an error when ESI could not tell if the region exist, the region is neither valid nor invalid.
+func doesRegionExist(regionId int, client *goredis.Client, esiClient *esi.Client) (bool, error) {
+	valValid, _ := client.SIsMember(context.Background(), namespace.Key("validRegions"), regionId).Result()
 
 	if valValid {
-		return true
+		return true, nil
 	}
 
-	valInvalid, errGetFromRedis := client.SIsMember(context.Background(), "invalidRegions", regionId).Result()
+	valInvalid, errGetFromRedis := client.SIsMember(context.Background(), namespace.Key("invalidRegions"), regionId).Result()
 
 	if valInvalid {
-		return false
+		return false, nil
 	}
 
+	var name string
 	if !valInvalid || errGetFromRedis != nil {
-		name, errGetFromEsi := extradata.GetRegionName(regionId)
+		var errGetFromEsi error
+		name, errGetFromEsi = extradata.GetRegionName(regionId, esiClient)
+
+		// Only a client error from ESI mark the region as invalid, not an outage
+		var statusErr *esi.StatusError
+		isClientErr := errors.As(errGetFromEsi, &statusErr) && statusErr.StatusCode < 500 && statusErr.StatusCode != 420
+		if errGetFromEsi != nil && !isClientErr {
+			return false, errGetFromEsi
+		}
 
 		if name == "" || errGetFromEsi != nil {
-			client.SAdd(context.Background(), "invalidRegions", regionId)
+			client.SAdd(context.Background(), namespace.Key("invalidRegions"), regionId)
+
+			return false, nil
+		}
+	}
+
+	added, _ := client.SAdd(context.Background(), namespace.Key("validRegions"), regionId).Result()
 
-			return false
+	if added == 1 {
+		if errPublish := notifier.Publish(notifier.EventRegionAdded, map[string]interface{}{"regionId": regionId, "regionName": name}, client); errPublish != nil {
+			log.Errorln(errPublish)
 		}
 	}
 
-	client.SAdd(context.Background(), "validRegions", regionId)
-	return true
+	return true, nil
 }